@@ -0,0 +1,228 @@
+// Package archiver 为 tar、tar.gz 和 zip 提供统一的列出、读取、解包、打包和格式转换
+package archiver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"time"
+
+	"standard-library-examples/archive/tar/tarutil"
+	"standard-library-examples/archive/zip/ziputil"
+)
+
+// Format 归档格式
+type Format int
+
+const (
+	Tar Format = iota + 1
+	TarGzip
+	Zip
+)
+
+func (f Format) String() string {
+	switch f {
+	case Tar:
+		return "tar"
+	case TarGzip:
+		return "tar.gz"
+	case Zip:
+		return "zip"
+	}
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// FormatError 不支持的格式，Format 为识别出的格式名称，无法识别时为 "unknown"
+type FormatError struct {
+	Format string
+}
+
+func (e *FormatError) Error() string {
+	return "archiver: unsupported archive format: " + e.Format
+}
+
+// UnsupportedError 条目无法写入目标格式，例如 zip 不能表示硬链接和设备文件
+type UnsupportedError struct {
+	Format Format
+	Name   string
+	Mode   fs.FileMode
+}
+
+func (e *UnsupportedError) Error() string {
+	kind := e.Mode.Type().String()
+	if e.Mode.IsRegular() {
+		kind = "hard link"
+	}
+	return fmt.Sprintf("archiver: %s cannot store %q (%s)", e.Format, e.Name, kind)
+}
+
+var (
+	zipSig      = []byte("PK\x03\x04")
+	emptyZipSig = []byte("PK\x05\x06")
+)
+
+// Detect 根据开头的魔数判断 r 中的归档格式，不会消耗 r 中的数据
+//
+// gzip 压缩的数据都识别为 TarGzip，解压后是否为 tar 在读取时才会检查。
+// 无法识别或者不支持的格式返回 *FormatError。
+func Detect(r *bufio.Reader) (Format, error) {
+	head, _ := r.Peek(len(zipSig))
+	if bytes.Equal(head, zipSig) || bytes.Equal(head, emptyZipSig) {
+		return Zip, nil
+	}
+	c, err := tarutil.Detect(r)
+	var formatErr *tarutil.FormatError
+	if errors.As(err, &formatErr) {
+		return 0, &FormatError{Format: formatErr.Format}
+	}
+	if err != nil {
+		return 0, err
+	}
+	switch c {
+	case tarutil.None:
+		return Tar, nil
+	case tarutil.Gzip:
+		return TarGzip, nil
+	}
+	return 0, &FormatError{Format: c.String()}
+}
+
+// Entry 归档中的一个条目
+type Entry struct {
+	Name     string      // 使用 "/" 分隔的路径，目录不带结尾的 "/"
+	Mode     fs.FileMode // 权限和类型，例如 fs.ModeDir、fs.ModeSymlink
+	Size     int64       // 普通文件的大小，-1 表示读完内容之前无法知道
+	ModTime  time.Time
+	Linkname string // 符号链接的目标；Mode 为普通文件时表示 tar 中的硬链接
+}
+
+// Info 返回描述条目的 fs.FileInfo
+func (e *Entry) Info() fs.FileInfo { return entryInfo{e} }
+
+type entryInfo struct{ e *Entry }
+
+func (fi entryInfo) Name() string       { return path.Base(fi.e.Name) }
+func (fi entryInfo) Size() int64        { return fi.e.Size }
+func (fi entryInfo) Mode() fs.FileMode  { return fi.e.Mode }
+func (fi entryInfo) ModTime() time.Time { return fi.e.ModTime }
+func (fi entryInfo) IsDir() bool        { return fi.e.Mode.IsDir() }
+func (fi entryInfo) Sys() any           { return nil }
+
+// Reader 按顺序读取归档中的条目，用法与 tar.Reader 相同：Next 前进到下一个条目，Read 读取它的内容
+type Reader interface {
+	Format() Format
+	// Next 前进到下一个条目，没有更多条目时返回 io.EOF
+	Next() (*Entry, error)
+	// Read 读取当前条目的内容
+	Read(p []byte) (int, error)
+	// Close 释放解压器和 OpenFile 打开的文件
+	Close() error
+}
+
+// Writer 写入归档
+type Writer interface {
+	// WriteEntry 写入条目 e，body 为普通文件的内容，其他类型的条目忽略 body
+	WriteEntry(e *Entry, body io.Reader) error
+	// Close 写入归档的结尾并刷新压缩器，不会关闭底层的 io.Writer
+	Close() error
+}
+
+// List 返回 r 中剩余的所有条目
+func List(r Reader) ([]Entry, error) {
+	var entries []Entry
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, *e)
+	}
+}
+
+// OpenEntry 跳过前面的条目，前进到名称为 name 的条目，之后通过 r.Read 读取它的内容
+//
+// 归档中没有该条目时返回包装了 fs.ErrNotExist 的错误。
+func OpenEntry(r Reader, name string) (*Entry, error) {
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e.Name == name {
+			return e, nil
+		}
+	}
+}
+
+// Convert 把 r 中剩余的条目依次写入 w，保留名称、权限和修改时间
+//
+// 目标格式不能表示的条目返回 *UnsupportedError，例如 tar 中的硬链接和设备文件无法写入 zip。
+func Convert(w Writer, r Reader) error {
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.WriteEntry(e, r); err != nil {
+			return err
+		}
+	}
+}
+
+// Extract 把 r 中的条目解包到 dir
+//
+// 所有格式都转换为 tar 流后交给 tarutil.Extract，因此共用同一套路径检查：
+// 指向 dir 之外的路径和链接返回包装了 tar.ErrInsecurePath 的错误，
+// 链接的目标和条目的路径按照磁盘上已经解包的内容检查，不会跟随之前解包的符号链接写入。
+func Extract(r Reader, dir string, opts *tarutil.ExtractOptions) error {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tw := newTarWriter(pw, nil)
+		err := Convert(tw, r)
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	err := tarutil.Extract(pr, dir, opts)
+	// 解包提前失败时，让转换的 goroutine 退出，返回之前等待它不再读取 r
+	pr.CloseWithError(errors.New("archiver: extract stopped"))
+	<-done
+	return err
+}
+
+// Create 把 fsys 打包为格式 f 写入 w
+//
+// fsys 实现了 ReadLink 方法时会保存符号链接，例如 tarutil.DirFS 的返回值。
+func Create(w io.Writer, f Format, fsys fs.FS) error {
+	switch f {
+	case Tar:
+		return tarutil.CreateFS(w, fsys, nil)
+	case TarGzip:
+		zw := gzip.NewWriter(w)
+		if err := tarutil.CreateFS(zw, fsys, nil); err != nil {
+			return err
+		}
+		return zw.Close()
+	case Zip:
+		return ziputil.CreateFS(w, fsys, nil)
+	}
+	return &FormatError{Format: f.String()}
+}
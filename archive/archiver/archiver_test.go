@@ -0,0 +1,311 @@
+package archiver_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"standard-library-examples/archive/archiver"
+	"standard-library-examples/archive/internal/archivetest"
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+var mtime = time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+// writeTree 创建测试用的目录树
+func writeTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	archivetest.WriteTree(t, dir, map[string]archivetest.File{
+		"README.md":      {Body: "# project"},
+		"bin/run.sh":     {Body: "#!/bin/sh\necho run", Mode: 0o755},
+		"src/main.go":    {Body: "package main"},
+		"src/lib/lib.go": {Body: "package lib"},
+		"main.go":        {Link: "src/main.go"},
+	}, mtime)
+	return dir
+}
+
+// create 把目录树打包为格式 f
+func create(t *testing.T, f archiver.Format, dir string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := archiver.Create(&buf, f, tarutil.DirFS(dir)); err != nil {
+		t.Fatalf("Create(%s): %v", f, err)
+	}
+	return buf.Bytes()
+}
+
+// list 读取 r 中的条目，符号链接的修改时间来自文件系统，不参与比较
+func list(t *testing.T, r archiver.Reader) []archiver.Entry {
+	t.Helper()
+	defer r.Close()
+	entries, err := archiver.List(r)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for i := range entries {
+		if entries[i].Mode&fs.ModeSymlink != 0 {
+			entries[i].ModTime = time.Time{}
+		}
+	}
+	return entries
+}
+
+func sameEntries(a, b []archiver.Entry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Name != y.Name || x.Mode != y.Mode || x.Size != y.Size || x.Linkname != y.Linkname || !x.ModTime.Equal(y.ModTime) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFormats(t *testing.T) {
+	dir := writeTree(t)
+	var want []archiver.Entry
+	for _, f := range []archiver.Format{archiver.Tar, archiver.TarGzip, archiver.Zip} {
+		r, err := archiver.NewReader(bytes.NewReader(create(t, f, dir)))
+		if err != nil {
+			t.Fatalf("NewReader(%s): %v", f, err)
+		}
+		if r.Format() != f {
+			t.Errorf("Format() = %s, want %s", r.Format(), f)
+		}
+		entries := list(t, r)
+		if want == nil {
+			want = entries
+			continue
+		}
+		if !sameEntries(entries, want) {
+			t.Errorf("%s entries:\n%v\nwant:\n%v", f, entries, want)
+		}
+	}
+
+	for _, e := range want {
+		switch e.Name {
+		case "bin/run.sh":
+			if e.Mode != 0o755 || !e.ModTime.Equal(mtime) {
+				t.Errorf("%s: mode %v mtime %v", e.Name, e.Mode, e.ModTime)
+			}
+		case "main.go":
+			if e.Mode&fs.ModeSymlink == 0 || e.Linkname != "src/main.go" {
+				t.Errorf("%s: mode %v link %q", e.Name, e.Mode, e.Linkname)
+			}
+		case "src":
+			if !e.Mode.IsDir() {
+				t.Errorf("%s: mode %v", e.Name, e.Mode)
+			}
+		}
+	}
+}
+
+// TestEmptyArchive 没有任何条目的归档可以识别并读回
+func TestEmptyArchive(t *testing.T) {
+	for _, f := range []archiver.Format{archiver.Tar, archiver.TarGzip, archiver.Zip} {
+		var buf bytes.Buffer
+		w, err := archiver.NewWriter(&buf, f)
+		if err != nil {
+			t.Fatalf("NewWriter(%s): %v", f, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close: %v", f, err)
+		}
+		r, err := archiver.NewReader(&buf)
+		if err != nil {
+			t.Fatalf("NewReader(%s): %v", f, err)
+		}
+		if r.Format() != f {
+			t.Errorf("Format() = %s, want %s", r.Format(), f)
+		}
+		if entries := list(t, r); len(entries) != 0 {
+			t.Errorf("%s: entries = %v", f, entries)
+		}
+	}
+}
+
+// TestConvert zip 转换为 tar.gz 后，名称、权限和修改时间保持不变
+func TestConvert(t *testing.T) {
+	dir := writeTree(t)
+	data := create(t, archiver.Zip, dir)
+
+	zr, _ := archiver.NewReader(bytes.NewReader(data))
+	var out bytes.Buffer
+	w, err := archiver.NewWriter(&out, archiver.TarGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := archiver.Convert(w, zr); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := archiver.NewReader(&out)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if tr.Format() != archiver.TarGzip {
+		t.Errorf("Format() = %s", tr.Format())
+	}
+	zr, _ = archiver.NewReader(bytes.NewReader(data))
+	if got, want := list(t, tr), list(t, zr); !sameEntries(got, want) {
+		t.Errorf("converted entries:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+// TestStreamZip 只有 io.Reader 时流式读取 zip，大小未知的条目仍然可以转换为 tar
+func TestStreamZip(t *testing.T) {
+	data := create(t, archiver.Zip, writeTree(t))
+	r, err := archiver.NewReader(io.MultiReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer r.Close()
+
+	var out bytes.Buffer
+	w, _ := archiver.NewWriter(&out, archiver.Tar)
+	if err := archiver.Convert(w, r); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	_ = w.Close()
+
+	tr, _ := archiver.NewReader(&out)
+	if _, err := archiver.OpenEntry(tr, "src/lib/lib.go"); err != nil {
+		t.Fatalf("OpenEntry: %v", err)
+	}
+	if b, _ := io.ReadAll(tr); string(b) != "package lib" {
+		t.Errorf("lib.go = %q", b)
+	}
+}
+
+func TestOpenFileExtract(t *testing.T) {
+	src := writeTree(t)
+	path := filepath.Join(t.TempDir(), "project.zip")
+	if err := os.WriteFile(path, create(t, archiver.Zip, src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := archiver.OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	dir := t.TempDir()
+	if err := archiver.Extract(r, dir, nil); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	r.Close()
+	fi, err := os.Stat(filepath.Join(dir, "bin/run.sh"))
+	if err != nil || fi.Mode().Perm() != 0o755 || !fi.ModTime().Equal(mtime) {
+		t.Errorf("run.sh: %v, %v", fi, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "main.go")); string(b) != "package main" {
+		t.Errorf("main.go via symlink = %q", b)
+	}
+
+	r, _ = archiver.OpenFile(path)
+	defer r.Close()
+	if _, err := archiver.OpenEntry(r, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("OpenEntry(missing) err = %v", err)
+	}
+}
+
+func TestExtractInsecure(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("../evil.txt")
+	_, _ = w.Write([]byte("evil"))
+	_ = zw.Close()
+
+	r, err := archiver.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	dir := t.TempDir()
+	if err := archiver.Extract(r, filepath.Join(dir, "out"), nil); !errors.Is(err, tar.ErrInsecurePath) {
+		t.Errorf("Extract err = %v, want tar.ErrInsecurePath", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); err == nil {
+		t.Errorf("evil.txt written outside the destination")
+	}
+}
+
+// TestExtractLinkChain zip 中的 y -> "."、x -> "y/.." 和目录 x/ 转换为 tar 后，
+// 不能借助磁盘上的链接修改解包目录的上级目录
+func TestExtractLinkChain(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range []struct {
+		name string
+		mode fs.FileMode
+		body string
+	}{
+		{"y", fs.ModeSymlink | 0o777, "."},
+		{"x", fs.ModeSymlink | 0o777, "y/.."},
+		{"x/", fs.ModeDir | 0o777, ""},
+	} {
+		fh := &zip.FileHeader{Name: e.name, Modified: mtime}
+		fh.SetMode(e.mode)
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(e.body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := archiver.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	root := t.TempDir()
+	if err := os.Chmod(root, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := archiver.Extract(r, filepath.Join(root, "out"), nil); !errors.Is(err, tar.ErrInsecurePath) {
+		t.Errorf("Extract err = %v, want tar.ErrInsecurePath", err)
+	}
+	if fi, _ := os.Stat(root); fi.Mode().Perm() != 0o700 {
+		t.Errorf("parent of the destination mode changed to %v", fi.Mode().Perm())
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	_, err := archiver.NewReader(strings.NewReader("\xfd7zXZ\x00 xz data"))
+	var formatErr *archiver.FormatError
+	if !errors.As(err, &formatErr) || formatErr.Format != "xz" {
+		t.Errorf("NewReader(xz) err = %v", err)
+	}
+
+	// tar 中的硬链接无法写入 zip
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "a", Mode: 0o644, Size: 1})
+	_, _ = tw.Write([]byte("a"))
+	_ = tw.WriteHeader(&tar.Header{Name: "b", Typeflag: tar.TypeLink, Linkname: "a", Mode: 0o644})
+	_ = tw.Close()
+
+	r, _ := archiver.NewReader(&buf)
+	w, _ := archiver.NewWriter(io.Discard, archiver.Zip)
+	var unsupported *archiver.UnsupportedError
+	if err := archiver.Convert(w, r); !errors.As(err, &unsupported) || unsupported.Name != "b" {
+		t.Errorf("Convert hard link err = %v", err)
+	}
+}
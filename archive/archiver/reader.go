@@ -0,0 +1,208 @@
+package archiver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"standard-library-examples/archive/tar/tarutil"
+	"standard-library-examples/archive/zip/ziputil"
+)
+
+// OpenFile 打开归档文件 name，zip 通过中央目录读取
+func OpenFile(name string) (Reader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newReader(f, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewReader 自动识别 r 的格式，返回读取其中条目的 Reader
+//
+// zip 的权限等属性只记录在中央目录中：r 实现了 io.ReaderAt 并且能够得到大小时（例如 *os.File、*bytes.Reader），
+// 通过中央目录读取；否则使用 ziputil.StreamReader 流式读取，条目没有权限信息，
+// 并且使用数据描述符的条目的 Size 为 -1。
+func NewReader(r io.Reader) (Reader, error) {
+	return newReader(r, nil)
+}
+
+func newReader(r io.Reader, file io.Closer) (Reader, error) {
+	br := bufio.NewReader(r)
+	f, err := Detect(br)
+	if err != nil {
+		return nil, err
+	}
+	if f != Zip {
+		tr, err := tarutil.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &tarReader{tr: tr, format: f, file: file}, nil
+	}
+
+	if ra, ok := r.(io.ReaderAt); ok {
+		if size, ok := sizeOf(r); ok {
+			zr, err := zip.NewReader(ra, size)
+			if err != nil {
+				return nil, err
+			}
+			return &zipReader{files: zr.File, file: file}, nil
+		}
+	}
+	return &zipStreamReader{sr: ziputil.NewStreamReader(br), file: file}, nil
+}
+
+// sizeOf 返回可以随机读取的 r 的大小
+func sizeOf(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size(), true
+	case interface{ Stat() (fs.FileInfo, error) }:
+		fi, err := r.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return 0, false
+		}
+		return fi.Size(), true
+	}
+	return 0, false
+}
+
+func closeFile(file io.Closer) error {
+	if file != nil {
+		return file.Close()
+	}
+	return nil
+}
+
+// tarReader 读取 tar 和 tar.gz
+type tarReader struct {
+	tr     *tarutil.Reader
+	format Format
+	file   io.Closer
+}
+
+func (r *tarReader) Format() Format             { return r.format }
+func (r *tarReader) Read(p []byte) (int, error) { return r.tr.Read(p) }
+
+func (r *tarReader) Next() (*Entry, error) {
+	hdr, err := r.tr.Next()
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		Name:     strings.TrimSuffix(hdr.Name, "/"),
+		Mode:     hdr.FileInfo().Mode(),
+		Size:     hdr.Size,
+		ModTime:  hdr.ModTime,
+		Linkname: hdr.Linkname,
+	}
+	if hdr.Typeflag == tar.TypeLink {
+		// 硬链接没有内容，Mode 中没有表示硬链接的类型位，按照普通文件处理
+		e.Mode = e.Mode.Perm()
+	}
+	return e, nil
+}
+
+func (r *tarReader) Close() error {
+	err := r.tr.Close()
+	if err1 := closeFile(r.file); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// zipReader 通过中央目录读取 zip
+type zipReader struct {
+	files []*zip.File
+	next  int
+	cur   io.ReadCloser
+	file  io.Closer
+}
+
+func (r *zipReader) Format() Format { return Zip }
+
+func (r *zipReader) Next() (*Entry, error) {
+	if r.cur != nil {
+		r.cur.Close()
+		r.cur = nil
+	}
+	if r.next >= len(r.files) {
+		return nil, io.EOF
+	}
+	f := r.files[r.next]
+	r.next++
+
+	e := &Entry{
+		Name:    strings.TrimSuffix(f.Name, "/"),
+		Mode:    f.Mode(),
+		Size:    int64(f.UncompressedSize64),
+		ModTime: f.Modified,
+	}
+	if e.Mode.IsRegular() || e.Mode&fs.ModeSymlink != 0 {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		r.cur = rc
+	}
+	if e.Mode&fs.ModeSymlink != 0 {
+		// zip 把符号链接的目标保存为条目的内容
+		target, err := io.ReadAll(r.cur)
+		if err != nil {
+			return nil, err
+		}
+		e.Linkname, e.Size = string(target), 0
+	}
+	return e, nil
+}
+
+func (r *zipReader) Read(p []byte) (int, error) {
+	if r.cur == nil {
+		return 0, io.EOF
+	}
+	return r.cur.Read(p)
+}
+
+func (r *zipReader) Close() error {
+	if r.cur != nil {
+		r.cur.Close()
+	}
+	return closeFile(r.file)
+}
+
+// zipStreamReader 不能随机读取时，按顺序读取 zip 的本地文件头
+type zipStreamReader struct {
+	sr   *ziputil.StreamReader
+	file io.Closer
+}
+
+func (r *zipStreamReader) Format() Format             { return Zip }
+func (r *zipStreamReader) Read(p []byte) (int, error) { return r.sr.Read(p) }
+func (r *zipStreamReader) Close() error               { return closeFile(r.file) }
+
+func (r *zipStreamReader) Next() (*Entry, error) {
+	fh, err := r.sr.Next()
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{
+		Name:    strings.TrimSuffix(fh.Name, "/"),
+		Mode:    fh.Mode(),
+		Size:    int64(fh.UncompressedSize64),
+		ModTime: fh.Modified,
+	}
+	if fh.Flags&0x8 != 0 && fh.UncompressedSize64 == 0 {
+		e.Size = -1
+	}
+	return e, nil
+}
@@ -0,0 +1,127 @@
+package archiver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// NewWriter 返回以格式 f 写入 w 的 Writer
+func NewWriter(w io.Writer, f Format) (Writer, error) {
+	switch f {
+	case Tar:
+		return newTarWriter(w, nil), nil
+	case TarGzip:
+		zw := gzip.NewWriter(w)
+		return newTarWriter(zw, zw), nil
+	case Zip:
+		return &zipWriter{zw: zip.NewWriter(w)}, nil
+	}
+	return nil, &FormatError{Format: f.String()}
+}
+
+// tarWriter 写入 tar，zw 不为 nil 时在 Close 中关闭外层的压缩器
+type tarWriter struct {
+	tw *tar.Writer
+	zw io.WriteCloser
+}
+
+func newTarWriter(w io.Writer, zw io.WriteCloser) *tarWriter {
+	return &tarWriter{tw: tar.NewWriter(w), zw: zw}
+}
+
+func (w *tarWriter) WriteEntry(e *Entry, body io.Reader) error {
+	link := ""
+	if e.Mode&fs.ModeSymlink != 0 {
+		link = e.Linkname
+	}
+	hdr, err := tar.FileInfoHeader(e.Info(), link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = e.Name
+	if e.Mode.IsDir() {
+		hdr.Name += "/"
+	}
+	if !e.Mode.IsRegular() {
+		return w.tw.WriteHeader(hdr)
+	}
+	if e.Linkname != "" {
+		hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeLink, e.Linkname, 0
+		return w.tw.WriteHeader(hdr)
+	}
+
+	if e.Size < 0 {
+		// tar 头部必须先写入大小，大小未知时先把内容保存到临时文件
+		tmp, err := os.CreateTemp("", "archiver-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if hdr.Size, err = io.Copy(tmp, body); err != nil {
+			return err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		body = tmp
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(w.tw, body)
+	return err
+}
+
+func (w *tarWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	if w.zw != nil {
+		return w.zw.Close()
+	}
+	return nil
+}
+
+// zipWriter 写入 zip，只支持普通文件、目录和符号链接
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipWriter) WriteEntry(e *Entry, body io.Reader) error {
+	switch {
+	case e.Mode.IsRegular() && e.Linkname != "",
+		!e.Mode.IsRegular() && !e.Mode.IsDir() && e.Mode&fs.ModeSymlink == 0:
+		return &UnsupportedError{Format: Zip, Name: e.Name, Mode: e.Mode}
+	}
+
+	fh, err := zip.FileInfoHeader(e.Info())
+	if err != nil {
+		return err
+	}
+	fh.Name = e.Name
+	if e.Size < 0 {
+		fh.UncompressedSize64 = 0
+	}
+	switch {
+	case e.Mode.IsDir():
+		fh.Name += "/"
+		fh.Method = zip.Store
+	case e.Mode&fs.ModeSymlink != 0:
+		fh.Method = zip.Store
+		body = strings.NewReader(e.Linkname)
+	}
+	fw, err := w.zw.CreateHeader(fh)
+	if err != nil || e.Mode.IsDir() {
+		return err
+	}
+	_, err = io.Copy(fw, body)
+	return err
+}
+
+func (w *zipWriter) Close() error { return w.zw.Close() }
@@ -0,0 +1,61 @@
+// Package archivetest 提供 archive 下各个包的测试共用的辅助函数
+package archivetest
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// File 目录树中的一个文件
+type File struct {
+	Body string
+	Mode fs.FileMode // 为 0 时使用 0o644
+	Link string      // 不为空时创建指向 Link 的符号链接，忽略 Body 和 Mode
+}
+
+// WriteTree 在 dir 中创建 files 描述的目录树，名称以 "/" 分隔，缺少的上级目录自动创建
+//
+// mtime 不为零值时，所有文件和目录的修改时间都设置为 mtime。
+// os.Chtimes 会跟随符号链接，因此符号链接本身的修改时间不会被设置。
+// 当前系统不支持符号链接时跳过测试。
+func WriteTree(tb testing.TB, dir string, files map[string]File, mtime time.Time) {
+	tb.Helper()
+	for name, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if f.Link != "" {
+			if err := os.Symlink(f.Link, p); err != nil {
+				tb.Skipf("symlink: %v", err)
+			}
+			continue
+		}
+		mode := f.Mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		if err := os.WriteFile(p, []byte(f.Body), mode); err != nil {
+			tb.Fatal(err)
+		}
+		// WriteFile 创建的文件受 umask 影响
+		if err := os.Chmod(p, mode); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if mtime.IsZero() {
+		return
+	}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink != 0 {
+			return err
+		}
+		return os.Chtimes(p, mtime, mtime)
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
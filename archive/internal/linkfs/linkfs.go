@@ -0,0 +1,37 @@
+// Package linkfs 在 os.DirFS 的基础上支持读取符号链接的目标
+//
+// fs.FS 本身不提供读取符号链接的方法，archive/tar/tarutil 和 archive/zip/ziputil
+// 打包目录时都通过这里的 DirFS 读取链接，两个包导出的 ReadLinkFS 是同一个接口。
+package linkfs
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ReadLinkFS 可以读取符号链接目标的文件系统
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// dirFS 在 os.DirFS 的基础上支持读取符号链接
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+// DirFS 返回以 dir 为根目录、实现了 ReadLinkFS 的文件系统
+func DirFS(dir string) ReadLinkFS {
+	return dirFS{FS: os.DirFS(dir), dir: dir}
+}
+
+// ReadLink 返回 name 指向的目标，name 不是 fs.ValidPath 格式时返回 fs.ErrInvalid，
+// 与 os.DirFS 的 Open 一样不会访问 dir 之外的路径
+func (d dirFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Readlink(filepath.Join(d.dir, filepath.FromSlash(name)))
+}
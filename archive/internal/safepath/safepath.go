@@ -0,0 +1,156 @@
+// Package safepath 解包时在目标目录之内解析条目的路径，不会跟随磁盘上已经存在的符号链接写入
+//
+// 只比较路径字符串是不够的：归档中先出现的符号链接会被写到磁盘上，
+// 之后的条目可以借助它们离开目标目录。例如依次解包 y -> "."、x -> "y/.." 和目录 x/，
+// 单看字符串 x 的目标 "y/.." 等于 "."，实际上却是目标目录的上级目录，
+// 对 x/ 执行 MkdirAll 和 Chmod 就会修改目标目录之外的目录。
+// 因此这里的每个函数都按照磁盘上的实际情况逐级检查路径。
+//
+// archive/tar/tarutil 和 archive/zip/ziputil 共用这些检查，
+// 路径不安全时返回 *Error，由调用者转换为各自包的错误类型。
+package safepath
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Error 条目的路径或者符号链接的目标不安全
+type Error struct {
+	Name   string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Reason)
+}
+
+// maxLinks 解析链接目标时最多跟随的符号链接数量，与 Linux 的 MAXSYMLINKS 相同
+const maxLinks = 40
+
+// Path 返回以 "/" 分隔的条目名称 name 在 dir 中的路径，并且创建缺少的上级目录
+//
+// name 必须是 dir 之内的相对路径，已经存在的上级目录不能是符号链接。
+// 返回的路径本身可能已经存在，写入之前需要用 Remove 删除，或者用 Mkdir 创建目录。
+func Path(dir, name string) (string, error) {
+	clean := path.Clean(strings.TrimSuffix(name, "/"))
+	if !filepath.IsLocal(filepath.FromSlash(clean)) || strings.HasPrefix(name, "/") {
+		return "", &Error{Name: name, Reason: "escapes the destination"}
+	}
+
+	parts := strings.Split(clean, "/")
+	p := dir
+	for _, part := range parts[:len(parts)-1] {
+		p = filepath.Join(p, part)
+		fi, err := os.Lstat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return "", &Error{Name: name, Reason: "traverses symlink " + part}
+		}
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(clean))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// Mkdir 创建目录条目 name 及其上级目录，返回磁盘上的路径
+//
+// name 已经作为符号链接存在时返回错误，而不是跟随链接，
+// 否则之后对目录设置权限和时间会作用在链接指向的位置。
+func Mkdir(dir, name string) (string, error) {
+	target, err := Path(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if fi, err := os.Lstat(target); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+		return "", &Error{Name: name, Reason: "directory is an existing symlink"}
+	}
+	return target, os.MkdirAll(target, 0o755)
+}
+
+// Chmod 设置 Mkdir 创建的目录 path 的权限，path 在此期间被替换为符号链接时返回错误
+func Chmod(path string, mode fs.FileMode) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return &Error{Name: path, Reason: "no longer a directory"}
+	}
+	return os.Chmod(path, mode)
+}
+
+// CheckLink 检查条目 name 作为指向 linkname 的符号链接时，目标是否位于 dir 之内
+//
+// 目标从 name 所在的目录开始逐级解析，遇到磁盘上已经存在的符号链接时跟随它继续解析，
+// 目标中尚不存在的部分按照字面解析。name 的上级目录需要已经通过 Path 检查。
+func CheckLink(dir, name, linkname string) error {
+	escape := &Error{Name: name, Reason: fmt.Sprintf("symlink target %q escapes the destination", linkname)}
+	if linkname == "" || strings.HasPrefix(linkname, "/") || filepath.IsAbs(linkname) {
+		return escape
+	}
+
+	var cur []string
+	if parent := path.Dir(path.Clean(strings.TrimSuffix(name, "/"))); parent != "." {
+		cur = strings.Split(parent, "/")
+	}
+	pending := strings.Split(filepath.ToSlash(linkname), "/")
+	links := 0
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(cur) == 0 {
+				return escape
+			}
+			cur = cur[:len(cur)-1]
+			continue
+		}
+
+		cur = append(cur, part)
+		p := filepath.Join(dir, filepath.Join(cur...))
+		fi, err := os.Lstat(p)
+		if err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+		if links++; links > maxLinks {
+			return &Error{Name: name, Reason: "too many levels of symlinks"}
+		}
+		target, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(target, "/") || filepath.IsAbs(target) {
+			return escape
+		}
+		cur = cur[:len(cur)-1]
+		pending = append(strings.Split(filepath.ToSlash(target), "/"), pending...)
+	}
+	return nil
+}
+
+// Remove 删除 path 上已经存在的文件或链接，目录和不存在的路径不会返回错误
+//
+// 写入普通文件和创建链接之前先删除旧的文件，再以 O_EXCL 创建，不会跟随旧的符号链接写入。
+func Remove(path string) error {
+	if fi, err := os.Lstat(path); err == nil && !fi.IsDir() {
+		return os.Remove(path)
+	}
+	return nil
+}
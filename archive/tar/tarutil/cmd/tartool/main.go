@@ -0,0 +1,132 @@
+// tartool 列出 tar 中的条目，或者比较两个 tar 的差异
+//
+// 用法：
+//
+//	tartool list [-json] [-hash] archive.tar.gz
+//	tartool diff [-json] [-ignore-mtime] old.tar new.tar.gz
+//
+// 支持未压缩的 tar 以及 gzip、bzip2、zlib 压缩的 tar。
+// diff 的退出码与 diff(1) 一致：0 表示没有差异，1 表示有差异，2 表示出错。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+const (
+	exitSame  = 0
+	exitDiff  = 1
+	exitError = 2
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	code := exitSame
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Args[2:], os.Stdout)
+	case "diff":
+		code, err = diff(os.Args[2:], os.Stdout)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tartool:", err)
+		os.Exit(exitError)
+	}
+	os.Exit(code)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: tartool list [-json] [-hash] archive")
+	fmt.Fprintln(os.Stderr, "       tartool diff [-json] [-ignore-mtime] old new")
+	os.Exit(exitError)
+}
+
+func list(args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print entries as JSON")
+	hash := flags.Bool("hash", false, "compute SHA-256 of file contents")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	entries, err := listFile(flags.Arg(0), *hash)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if entries == nil {
+			// 空的 tar 输出 []，而不是 null
+			entries = []tarutil.Entry{}
+		}
+		return writeJSON(w, entries)
+	}
+	return tarutil.WriteTable(w, entries)
+}
+
+func diff(args []string, w io.Writer) (int, error) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print changes as JSON")
+	ignoreMTime := flags.Bool("ignore-mtime", false, "do not compare modification times")
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		usage()
+	}
+
+	older, err := listFile(flags.Arg(0), true)
+	if err != nil {
+		return exitError, err
+	}
+	newer, err := listFile(flags.Arg(1), true)
+	if err != nil {
+		return exitError, err
+	}
+
+	changes := tarutil.Diff(older, newer, &tarutil.DiffOptions{IgnoreModTime: *ignoreMTime})
+	if *asJSON {
+		if changes == nil {
+			// 没有变化时输出 []，而不是 null
+			changes = []tarutil.Change{}
+		}
+		err = writeJSON(w, changes)
+	} else if len(changes) > 0 {
+		err = tarutil.WriteDiffTable(w, changes)
+	}
+	if err != nil {
+		return exitError, err
+	}
+	if len(changes) > 0 {
+		return exitDiff, nil
+	}
+	return exitSame, nil
+}
+
+func listFile(name string, hash bool) ([]tarutil.Entry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := tarutil.List(f, hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return entries, nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
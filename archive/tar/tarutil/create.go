@@ -0,0 +1,161 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"standard-library-examples/archive/internal/linkfs"
+)
+
+// CreateOptions 打包时的选项
+type CreateOptions struct {
+	// Include 不为空时只打包匹配其中任意一个模式的条目，目录仍然会被遍历
+	Include []string
+	// Exclude 跳过匹配的条目，匹配的目录连同其中的内容一起跳过
+	Exclude []string
+	// Reproducible 为 true 时，相同的目录树总是生成完全相同的字节：
+	// 所有条目的修改时间都设置为 ModTime，所有者设置为 0/root，并且不记录访问时间和状态改变时间
+	Reproducible bool
+	// ModTime Reproducible 为 true 时使用的修改时间，零值表示 Unix 纪元
+	ModTime time.Time
+}
+
+// ReadLinkFS 可以读取符号链接目标的文件系统
+//
+// fs.FS 本身不提供读取符号链接的方法，实现了该接口的文件系统才能打包符号链接，
+// 否则符号链接会按照普通文件处理
+type ReadLinkFS = linkfs.ReadLinkFS
+
+// DirFS 返回以 dir 为根目录、实现了 ReadLinkFS 的文件系统
+func DirFS(dir string) ReadLinkFS {
+	return linkfs.DirFS(dir)
+}
+
+// Create 把目录 dir 打包为 tar 写入 w
+func Create(w io.Writer, dir string, opts *CreateOptions) error {
+	return CreateFS(w, DirFS(dir), opts)
+}
+
+// CreateFS 把文件系统 fsys 打包为 tar 写入 w，fsys 可以是 embed.FS、os.DirFS 等任意 fs.FS
+//
+// 支持普通文件、目录、符号链接（fsys 需要实现 ReadLinkFS）、硬链接（同一个 inode 只保存一次内容）
+// 和 FIFO，超过 USTAR 长度限制的文件名由 tar.Writer 自动使用 PAX 格式保存。
+// fs.WalkDir 按照字典序遍历，因此条目的顺序是确定的。
+//
+// fsys 没有实现 ReadLinkFS 时，指向文件的符号链接按照普通文件保存；
+// 指向目录的符号链接返回错误，因为 fs.WalkDir 不会进入其中，目录的内容会被悄悄丢掉。
+func CreateFS(w io.Writer, fsys fs.FS, opts *CreateOptions) error {
+	if opts == nil {
+		opts = &CreateOptions{}
+	}
+	tw := tar.NewWriter(w)
+	links := make(map[any]string)
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		if matchAny(opts.Exclude, name) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if len(opts.Include) > 0 && !matchAny(opts.Include, name) {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var linkname string
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			if rl, ok := fsys.(ReadLinkFS); ok {
+				if linkname, err = rl.ReadLink(name); err != nil {
+					return err
+				}
+			} else if fi, err = fs.Stat(fsys, name); err != nil {
+				return err
+			} else if fi.IsDir() {
+				return fmt.Errorf("tarutil: %s is a symlink to a directory and fsys cannot read symlinks", name)
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(fi, linkname)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if fi.Mode().IsRegular() {
+			if key, ok := inodeKey(fi); ok {
+				if first, ok := links[key]; ok {
+					hdr.Typeflag = tar.TypeLink
+					hdr.Linkname = first
+					hdr.Size = 0
+				} else {
+					links[key] = name
+				}
+			}
+		}
+		if opts.Reproducible {
+			makeReproducible(hdr, opts.ModTime)
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// makeReproducible 去掉头部中与构建环境有关的信息
+func makeReproducible(hdr *tar.Header, mtime time.Time) {
+	if mtime.IsZero() {
+		mtime = time.Unix(0, 0)
+	}
+	hdr.ModTime = mtime.UTC()
+	hdr.AccessTime = time.Time{}
+	hdr.ChangeTime = time.Time{}
+	hdr.Uid, hdr.Gid = 0, 0
+	hdr.Uname, hdr.Gname = "root", "root"
+	hdr.PAXRecords = nil
+	hdr.Format = tar.FormatPAX
+}
+
+// matchAny 判断 name 的完整路径或者文件名是否匹配 patterns 中的任意一个
+func matchAny(patterns []string, name string) bool {
+	base := path.Base(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
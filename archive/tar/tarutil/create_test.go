@@ -0,0 +1,136 @@
+package tarutil_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"standard-library-examples/archive/internal/archivetest"
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+// readNames 返回 tar 数据中的条目名称和类型
+func readNames(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		names = append(names, string(hdr.Typeflag)+" "+hdr.Name)
+	}
+}
+
+// writeTree 在 dir 中创建测试用的目录树，所有文件的修改时间都设置为 mtime
+func writeTree(t *testing.T, dir string, mtime time.Time) {
+	t.Helper()
+	archivetest.WriteTree(t, dir, map[string]archivetest.File{
+		"a.txt":                   {Body: "a"},
+		"b/c.txt":                 {Body: "c"},
+		"b/skip.log":              {Body: "log"},
+		strings.Repeat("x", 120):  {Body: "long name"},
+		"vendor/module/module.go": {Body: "package module"},
+		"link":                    {Link: "b/c.txt"},
+	}, mtime)
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, time.Now())
+
+	var buf bytes.Buffer
+	opts := &tarutil.CreateOptions{Exclude: []string{"*.log", "vendor"}}
+	if err := tarutil.Create(&buf, dir, opts); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	want := []string{"0 a.txt", "5 b/", "0 b/c.txt", "2 link", "0 " + strings.Repeat("x", 120)}
+	if got := readNames(t, &buf); !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
+
+func TestCreateInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, time.Now())
+
+	var buf bytes.Buffer
+	if err := tarutil.Create(&buf, dir, &tarutil.CreateOptions{Include: []string{"*.txt"}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	want := []string{"0 a.txt", "0 b/c.txt"}
+	if got := readNames(t, &buf); !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
+
+// TestCreateReproducible 修改时间不同的两棵相同目录树生成相同的字节
+func TestCreateReproducible(t *testing.T) {
+	var outputs [2]bytes.Buffer
+	for i := range outputs {
+		dir := t.TempDir()
+		writeTree(t, dir, time.Now().Add(time.Duration(i)*time.Hour))
+		opts := &tarutil.CreateOptions{Reproducible: true}
+		if err := tarutil.Create(&outputs[i], dir, opts); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if !bytes.Equal(outputs[0].Bytes(), outputs[1].Bytes()) {
+		t.Errorf("reproducible archives differ")
+	}
+}
+
+func TestCreateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":     {Data: []byte("<h1>hi</h1>"), Mode: 0o644},
+		"static/app.js":  {Data: []byte("alert(1)"), Mode: 0o644},
+		"static/app.css": {Data: []byte("body{}"), Mode: 0o600},
+	}
+
+	var buf bytes.Buffer
+	if err := tarutil.CreateFS(&buf, fsys, nil); err != nil {
+		t.Fatalf("CreateFS: %v", err)
+	}
+	want := []string{"0 index.html", "5 static/", "0 static/app.css", "0 static/app.js"}
+	if got := readNames(t, &buf); !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
+
+// TestCreateSymlinkDir fsys 不能读取符号链接时，指向目录的符号链接返回错误，而不是丢掉目录的内容
+func TestCreateSymlinkDir(t *testing.T) {
+	dir := t.TempDir()
+	archivetest.WriteTree(t, dir, map[string]archivetest.File{
+		"real/a.txt": {Body: "a"},
+		"link":       {Link: "real"},
+	}, time.Time{})
+
+	// 只保留 Open 方法，隐藏 os.DirFS 可能实现的 ReadLink
+	fsys := struct{ fs.FS }{os.DirFS(dir)}
+	err := tarutil.CreateFS(io.Discard, fsys, nil)
+	if err == nil || !strings.Contains(err.Error(), "link") {
+		t.Errorf("CreateFS err = %v, want symlink to directory error", err)
+	}
+
+	// 实现了 ReadLinkFS 时作为符号链接保存
+	var buf bytes.Buffer
+	if err := tarutil.Create(&buf, dir, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	want := []string{"2 link", "5 real/", "0 real/a.txt"}
+	if got := readNames(t, &buf); !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
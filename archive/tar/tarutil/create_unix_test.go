@@ -0,0 +1,37 @@
+//go:build unix
+
+package tarutil_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+// TestCreateSpecialFiles 与 testdata 中的 file、hardlink、fifo 对应
+func TestCreateSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(dir, "file"), filepath.Join(dir, "hardlink")); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(dir, "fifo"), 0o644); err != nil {
+		t.Skipf("Mkfifo: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tarutil.Create(&buf, dir, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	want := []string{"6 fifo", "0 file", "1 hardlink"}
+	if got := readNames(t, &buf); !reflect.DeepEqual(got, want) {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+// Package tarutil 在 archive/tar 的基础上提供解包、打包、压缩格式识别等常用操作
+package tarutil
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"standard-library-examples/archive/internal/safepath"
+)
+
+var (
+	// ErrTooManyEntries 条目数量超过 ExtractOptions.MaxEntries
+	ErrTooManyEntries = errors.New("tarutil: too many entries")
+	// ErrTooLarge 文件总大小超过 ExtractOptions.MaxSize
+	ErrTooLarge = errors.New("tarutil: archive too large")
+)
+
+// ExtractOptions 解包时的限制，零值表示不限制
+type ExtractOptions struct {
+	MaxEntries int   // 最多解包的条目数量
+	MaxSize    int64 // 所有普通文件的总字节数上限
+}
+
+// Extract 把 r 中的 tar 数据解包到 dir 目录
+//
+// 以下条目会被拒绝并返回包装了 tar.ErrInsecurePath 的错误：
+//
+//   - 绝对路径，或者包含 ".." 从而指向 dir 之外的路径
+//   - 目标指向 dir 之外的符号链接和硬链接，符号链接的目标按照磁盘上已经存在的链接逐级解析
+//   - 路径中经过了符号链接的条目，避免先创建指向内部的链接，再借助它写到 dir 之外
+//   - 已经作为符号链接存在的目录条目
+//
+// 普通文件、目录、符号链接和硬链接会被还原，并设置权限和修改时间；
+// 字符设备、块设备和 FIFO 会被跳过。
+func Extract(r io.Reader, dir string, opts *ExtractOptions) error {
+	if opts == nil {
+		opts = &ExtractOptions{}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	type dirTime struct {
+		path  string
+		mode  fs.FileMode
+		mtime time.Time
+	}
+	var dirs []dirTime
+
+	tr := tar.NewReader(r)
+	var entries int
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		entries++
+		if opts.MaxEntries > 0 && entries > opts.MaxEntries {
+			return fmt.Errorf("%w: more than %d", ErrTooManyEntries, opts.MaxEntries)
+		}
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+			total += hdr.Size
+			if opts.MaxSize > 0 && total > opts.MaxSize {
+				return fmt.Errorf("%w: more than %d bytes", ErrTooLarge, opts.MaxSize)
+			}
+		}
+
+		mode := hdr.FileInfo().Mode().Perm()
+		if hdr.Typeflag == tar.TypeDir {
+			target, err := safepath.Mkdir(dir, hdr.Name)
+			if err != nil {
+				return insecure(err)
+			}
+			// 目录的权限和时间在所有文件写入之后再设置，避免目录不可写或者时间被修改
+			dirs = append(dirs, dirTime{target, mode, hdr.ModTime})
+			continue
+		}
+
+		target, err := safepath.Path(dir, hdr.Name)
+		if err != nil {
+			return insecure(err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeFile(target, tr, mode); err != nil {
+				return err
+			}
+			if err := os.Chtimes(target, hdr.ModTime, hdr.ModTime); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := safepath.CheckLink(dir, hdr.Name, hdr.Linkname); err != nil {
+				return insecure(err)
+			}
+			if err := replace(target, func() error { return os.Symlink(hdr.Linkname, target) }); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			source, err := safepath.Path(dir, hdr.Linkname)
+			if err != nil {
+				return insecure(err)
+			}
+			if err := replace(target, func() error { return os.Link(source, target) }); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := safepath.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return insecure(err)
+		}
+		if err := os.Chtimes(dirs[i].path, dirs[i].mtime, dirs[i].mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insecure 把 safepath 的错误转换为包装了 tar.ErrInsecurePath 的错误
+func insecure(err error) error {
+	var pe *safepath.Error
+	if errors.As(err, &pe) {
+		return fmt.Errorf("%w: %v", tar.ErrInsecurePath, pe)
+	}
+	return err
+}
+
+// writeFile 创建 path 并写入 r 的内容，已经存在的文件或链接会先被删除，不会跟随符号链接写入
+func writeFile(path string, r io.Reader, mode fs.FileMode) error {
+	return replace(path, func() error {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		// OpenFile 的权限会受到 umask 影响
+		return os.Chmod(path, mode)
+	})
+}
+
+// replace 删除 path 上已经存在的非目录文件后执行 create
+func replace(path string, create func() error) error {
+	if err := safepath.Remove(path); err != nil {
+		return err
+	}
+	return create()
+}
@@ -0,0 +1,192 @@
+package tarutil_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+// entry 测试中用来构造 tar 的条目
+type entry struct {
+	hdr  tar.Header
+	body string
+}
+
+// buildTar 在内存中构造 tar 数据
+func buildTar(t *testing.T, entries ...entry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := e.hdr
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(e.body))
+		}
+		if hdr.Mode == 0 {
+			hdr.Mode = 0o644
+		}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatalf("WriteHeader(%s): %v", hdr.Name, err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatalf("Write(%s): %v", hdr.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return &buf
+}
+
+func file(name, body string) entry {
+	return entry{tar.Header{Name: name, Typeflag: tar.TypeReg}, body}
+}
+
+func TestExtract(t *testing.T) {
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	buf := buildTar(t,
+		entry{hdr: tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o750, ModTime: mtime}},
+		entry{tar.Header{Name: "dir/run.sh", Typeflag: tar.TypeReg, Mode: 0o755, ModTime: mtime}, "#!/bin/sh\n"},
+		file("dir/sub/readme.txt", "hello"),
+		entry{hdr: tar.Header{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "sub/readme.txt"}},
+		entry{hdr: tar.Header{Name: "hard", Typeflag: tar.TypeLink, Linkname: "dir/run.sh"}},
+		entry{hdr: tar.Header{Name: "fifo", Typeflag: tar.TypeFifo}},
+	)
+
+	dir := t.TempDir()
+	if err := tarutil.Extract(buf, dir, nil); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, "dir/run.sh"))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fi.Mode().Perm() != 0o755 || !fi.ModTime().Equal(mtime) {
+		t.Errorf("run.sh mode = %v mtime = %v", fi.Mode(), fi.ModTime())
+	}
+	fi, _ = os.Stat(filepath.Join(dir, "dir"))
+	if fi.Mode().Perm() != 0o750 || !fi.ModTime().Equal(mtime) {
+		t.Errorf("dir mode = %v mtime = %v", fi.Mode(), fi.ModTime())
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "dir/link")); string(b) != "hello" {
+		t.Errorf("symlink content = %q", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "hard")); string(b) != "#!/bin/sh\n" {
+		t.Errorf("hardlink content = %q", b)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "fifo")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("fifo should be skipped, Lstat err = %v", err)
+	}
+}
+
+func TestExtractInsecure(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{"absolute path", []entry{file("/etc/passwd", "x")}},
+		{"dot dot", []entry{file("a/../../evil", "x")}},
+		{"absolute symlink", []entry{{hdr: tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc"}}}},
+		{"relative symlink", []entry{{hdr: tar.Header{Name: "a/link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}}}},
+		{"hardlink outside", []entry{{hdr: tar.Header{Name: "hard", Typeflag: tar.TypeLink, Linkname: "../secret"}}}},
+		// 第一个链接本身指向内部，但是借助它可以让第二个链接指向外部
+		{"chained symlink", []entry{
+			{hdr: tar.Header{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."}},
+			{hdr: tar.Header{Name: "a/b", Typeflag: tar.TypeSymlink, Linkname: ".."}},
+		}},
+		{"write through symlink", []entry{
+			{hdr: tar.Header{Name: "sub", Typeflag: tar.TypeSymlink, Linkname: "."}},
+			file("sub/file", "x"),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "out")
+			err := tarutil.Extract(buildTar(t, tt.entries...), dir, nil)
+			if !errors.Is(err, tar.ErrInsecurePath) {
+				t.Errorf("err = %v, want tar.ErrInsecurePath", err)
+			}
+		})
+	}
+}
+
+// TestExtractLinkChain y -> "." 和 x -> "y/.." 单看字符串都指向内部，
+// 但是在磁盘上 x 指向解包目录的上级目录，之后的目录条目 x/ 不能修改它的权限
+func TestExtractLinkChain(t *testing.T) {
+	entries := []entry{
+		{hdr: tar.Header{Name: "y", Typeflag: tar.TypeSymlink, Linkname: "."}},
+		{hdr: tar.Header{Name: "x", Typeflag: tar.TypeSymlink, Linkname: "y/.."}},
+		{hdr: tar.Header{Name: "x/", Typeflag: tar.TypeDir, Mode: 0o777}},
+	}
+	root := t.TempDir()
+	if err := os.Chmod(root, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "out")
+	err := tarutil.Extract(buildTar(t, entries...), dir, nil)
+	if !errors.Is(err, tar.ErrInsecurePath) {
+		t.Errorf("err = %v, want tar.ErrInsecurePath", err)
+	}
+	if fi, _ := os.Stat(root); fi.Mode().Perm() != 0o700 {
+		t.Errorf("parent of the destination mode changed to %v", fi.Mode().Perm())
+	}
+
+	// 目标目录中已经存在指向外部的链接时，目录条目也不能跟随它
+	dir = filepath.Join(root, "existing")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..", filepath.Join(dir, "x")); err != nil {
+		t.Fatal(err)
+	}
+	err = tarutil.Extract(buildTar(t, entries[2]), dir, nil)
+	if !errors.Is(err, tar.ErrInsecurePath) {
+		t.Errorf("existing symlink: err = %v, want tar.ErrInsecurePath", err)
+	}
+	if fi, _ := os.Stat(root); fi.Mode().Perm() != 0o700 {
+		t.Errorf("existing symlink: parent mode changed to %v", fi.Mode().Perm())
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	entries := []entry{file("a", "1234"), file("b", "5678"), file("c", "9")}
+
+	err := tarutil.Extract(buildTar(t, entries...), t.TempDir(), &tarutil.ExtractOptions{MaxEntries: 2})
+	if !errors.Is(err, tarutil.ErrTooManyEntries) {
+		t.Errorf("err = %v, want ErrTooManyEntries", err)
+	}
+
+	err = tarutil.Extract(buildTar(t, entries...), t.TempDir(), &tarutil.ExtractOptions{MaxSize: 8})
+	if !errors.Is(err, tarutil.ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+
+	err = tarutil.Extract(buildTar(t, entries...), t.TempDir(), &tarutil.ExtractOptions{MaxEntries: 3, MaxSize: 9})
+	if err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+func TestExtractTestdata(t *testing.T) {
+	f, err := os.Open("../testdata/hdr-only.tar")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	dir := t.TempDir()
+	if err := tarutil.Extract(f, dir, nil); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	t.Logf("extracted: %v", names)
+}
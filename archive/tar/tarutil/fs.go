@@ -0,0 +1,385 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// indexVersion 序列化格式的版本，格式变化时递增
+const indexVersion = 1
+
+// IndexEntry 一个条目的头部信息，以及数据的偏移
+//
+// Offset 是数据在 BuildIndex 读取的 io.ReadSeeker 中的绝对位置，而不是相对于 tar 开头的位置，
+// 因此 tar 位于文件中间时 (例如前面有其他数据)，NewFS 可以直接用同一个文件作为 io.ReaderAt。
+type IndexEntry struct {
+	Name     string    `json:"name"`
+	Typeflag byte      `json:"type"`
+	Linkname string    `json:"link,omitempty"`
+	Mode     int64     `json:"mode"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mtime"`
+	Offset   int64     `json:"offset"`
+}
+
+// Index tar 的索引，可以通过 WriteTo 保存，之后用 ReadIndex 读取，避免再次扫描 tar
+type Index struct {
+	Version int          `json:"version"`
+	Entries []IndexEntry `json:"entries"`
+}
+
+// offsetReader 记录已经读取的字节数，实现 io.Seeker 使 tar.Reader 可以直接跳过文件内容
+type offsetReader struct {
+	r   io.ReadSeeker
+	pos int64
+}
+
+func (o *offsetReader) Read(p []byte) (int, error) {
+	n, err := o.r.Read(p)
+	o.pos += int64(n)
+	return n, err
+}
+
+func (o *offsetReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := o.r.Seek(offset, whence)
+	if err == nil {
+		o.pos = pos
+	}
+	return pos, err
+}
+
+// BuildIndex 从 r 的当前位置开始扫描一遍未压缩的 tar，记录每个条目的数据在 r 中的绝对偏移
+//
+// 同名的条目只保留最后一个，与解包后的结果一致。稀疏文件的数据不连续，无法建立索引
+func BuildIndex(r io.ReadSeeker) (*Index, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	or := &offsetReader{r: r, pos: start}
+	tr := tar.NewReader(or)
+
+	idx := &Index{Version: indexVersion}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return idx, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isSparse(hdr) {
+			return nil, fmt.Errorf("tarutil: sparse file %s cannot be indexed", hdr.Name)
+		}
+		idx.Entries = append(idx.Entries, IndexEntry{
+			Name:     hdr.Name,
+			Typeflag: hdr.Typeflag,
+			Linkname: hdr.Linkname,
+			Mode:     hdr.Mode,
+			Size:     hdr.Size,
+			ModTime:  hdr.ModTime,
+			Offset:   or.pos,
+		})
+	}
+}
+
+func isSparse(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTo 以 JSON 格式保存索引
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// ReadIndex 读取 WriteTo 保存的索引
+func ReadIndex(r io.Reader) (*Index, error) {
+	var idx Index
+	if err := json.NewDecoder(r).Decode(&idx); err != nil {
+		return nil, err
+	}
+	if idx.Version != indexVersion {
+		return nil, fmt.Errorf("tarutil: unsupported index version %d", idx.Version)
+	}
+	return &idx, nil
+}
+
+// node 文件系统中的一个文件或目录，implicit 表示 tar 中没有对应条目、由子条目推断出的目录
+type node struct {
+	entry    IndexEntry
+	children []string
+	implicit bool
+}
+
+func (n *node) header() *tar.Header {
+	return &tar.Header{
+		Name:     n.entry.Name,
+		Typeflag: n.entry.Typeflag,
+		Linkname: n.entry.Linkname,
+		Mode:     n.entry.Mode,
+		Size:     n.entry.Size,
+		ModTime:  n.entry.ModTime,
+	}
+}
+
+// FS 把 tar 作为只读的 fs.FS，实现了 fs.ReadDirFS 和 fs.StatFS
+//
+// 普通文件通过 io.ReaderAt 随机读取，不需要从头扫描 tar；
+// 符号链接和硬链接在 archive 内部解析，指向 archive 之外的链接视为不存在。
+// 返回的文件实现了 io.Seeker 和 io.ReaderAt，可以用于 http.FS。
+type FS struct {
+	r     io.ReaderAt
+	nodes map[string]*node
+}
+
+var (
+	_ fs.ReadDirFS = (*FS)(nil)
+	_ fs.StatFS    = (*FS)(nil)
+)
+
+// OpenFS 扫描 r 建立索引，并返回对应的 FS
+func OpenFS(r interface {
+	io.ReaderAt
+	io.ReadSeeker
+}) (*FS, error) {
+	idx, err := BuildIndex(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewFS(r, idx), nil
+}
+
+// NewFS 使用已经建立好的索引返回 FS，idx 中的偏移是 r 中的绝对位置，r 应该是建立索引时读取的同一个文件
+func NewFS(r io.ReaderAt, idx *Index) *FS {
+	fsys := &FS{r: r, nodes: map[string]*node{
+		".": {entry: IndexEntry{Name: ".", Typeflag: tar.TypeDir, Mode: 0o555}, implicit: true},
+	}}
+	for _, e := range idx.Entries {
+		name := cleanName(e.Name)
+		if name == "" {
+			continue
+		}
+		e.Name = name
+		fsys.add(name, &node{entry: e})
+	}
+	for _, n := range fsys.nodes {
+		sort.Strings(n.children)
+	}
+	return fsys
+}
+
+// cleanName 把 tar 中的名称转换为 fs.ValidPath 格式，无效的名称返回 ""
+func cleanName(name string) string {
+	name = path.Clean(strings.TrimPrefix(name, "./"))
+	if name == "." || !fs.ValidPath(name) {
+		return ""
+	}
+	return name
+}
+
+// add 添加 n，并创建不存在的上级目录
+func (fsys *FS) add(name string, n *node) {
+	if old, ok := fsys.nodes[name]; ok {
+		n.children = old.children
+	} else {
+		parent := path.Dir(name)
+		if _, ok := fsys.nodes[parent]; !ok {
+			fsys.add(parent, &node{
+				entry:    IndexEntry{Name: parent, Typeflag: tar.TypeDir, Mode: 0o555},
+				implicit: true,
+			})
+		}
+		fsys.nodes[parent].children = append(fsys.nodes[parent].children, path.Base(name))
+	}
+	fsys.nodes[name] = n
+}
+
+// maxLinks 解析链接时最多跟随的次数，防止循环链接
+const maxLinks = 40
+
+// lookup 返回 name 对应的节点，follow 为 true 时解析最后一个路径元素的符号链接
+func (fsys *FS) lookup(name string, follow bool) (*node, error) {
+	var parts []string
+	if name != "." {
+		parts = strings.Split(name, "/")
+	}
+	resolved := "."
+	links := 0
+	for len(parts) > 0 {
+		next := path.Join(resolved, parts[0])
+		parts = parts[1:]
+		n, ok := fsys.nodes[next]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+
+		switch n.entry.Typeflag {
+		case tar.TypeSymlink:
+			if len(parts) == 0 && !follow {
+				return n, nil
+			}
+			if links++; links > maxLinks {
+				return nil, errors.New("tarutil: too many links")
+			}
+			target, ok := resolveLink(resolved, n.entry.Linkname)
+			if !ok {
+				return nil, fs.ErrNotExist
+			}
+			// 从根目录开始重新解析链接目标和剩下的路径元素
+			resolved = "."
+			if target != "." {
+				parts = append(strings.Split(target, "/"), parts...)
+			}
+			continue
+		case tar.TypeLink:
+			if n, ok = fsys.nodes[cleanName(n.entry.Linkname)]; !ok {
+				return nil, fs.ErrNotExist
+			}
+		}
+
+		if len(parts) == 0 {
+			return n, nil
+		}
+		if n.entry.Typeflag != tar.TypeDir {
+			return nil, fs.ErrNotExist
+		}
+		resolved = next
+	}
+	return fsys.nodes["."], nil
+}
+
+// resolveLink 返回目录 dir 中的符号链接 link 指向的路径，指向 archive 之外时返回 false
+func resolveLink(dir, link string) (string, bool) {
+	if path.IsAbs(link) {
+		return "", false
+	}
+	target := path.Join(dir, link)
+	if target != "." && !fs.ValidPath(target) {
+		return "", false
+	}
+	return target, true
+}
+
+// Open 打开 name，实现 fs.FS
+func (fsys *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	n, err := fsys.lookup(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	info := fileInfo{n.header().FileInfo(), name}
+	switch n.entry.Typeflag {
+	case tar.TypeDir:
+		return &dir{fsys: fsys, node: n, info: info}, nil
+	case tar.TypeReg, tar.TypeRegA:
+		return &file{SectionReader: io.NewSectionReader(fsys.r, n.entry.Offset, n.entry.Size), info: info}, nil
+	}
+	// 设备文件、FIFO 等没有内容
+	return &file{SectionReader: io.NewSectionReader(fsys.r, 0, 0), info: info}, nil
+}
+
+// Stat 返回 name 的信息，符号链接返回其目标的信息，实现 fs.StatFS
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	n, err := fsys.lookup(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return fileInfo{n.header().FileInfo(), name}, nil
+}
+
+// ReadDir 返回目录中按名称排序的条目，实现 fs.ReadDirFS
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := f.(*dir)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return d.ReadDir(-1)
+}
+
+// fileInfo 使用打开时的路径作为名称，而不是链接目标的名称
+type fileInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (fi fileInfo) Name() string {
+	return path.Base(fi.name)
+}
+
+// file 普通文件
+type file struct {
+	*io.SectionReader
+	info fs.FileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// dir 目录，实现 fs.ReadDirFile
+type dir struct {
+	fsys   *FS
+	node   *node
+	info   fs.FileInfo
+	offset int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.node.entry.Name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) ReadDir(count int) ([]fs.DirEntry, error) {
+	names := d.node.children[d.offset:]
+	if count > 0 && len(names) > count {
+		names = names[:count]
+	}
+	if count > 0 && len(names) == 0 {
+		return nil, io.EOF
+	}
+
+	entries := make([]fs.DirEntry, len(names))
+	for i, name := range names {
+		child := d.fsys.nodes[path.Join(d.node.entry.Name, name)]
+		// 硬链接使用目标的信息，与 Open 之后 Stat 的结果一致
+		if child.entry.Typeflag == tar.TypeLink {
+			if target, ok := d.fsys.nodes[cleanName(child.entry.Linkname)]; ok {
+				child = target
+			}
+		}
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{child.header().FileInfo(), name})
+	}
+	d.offset += len(names)
+	return entries, nil
+}
@@ -0,0 +1,163 @@
+package tarutil_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+func buildSiteTar(t *testing.T) *bytes.Reader {
+	t.Helper()
+	buf := buildTar(t,
+		file("./index.html", "<h1>index</h1>"),
+		entry{hdr: tar.Header{Name: "templates/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		file("templates/hello.tmpl", `{{define "hello"}}Hello, {{.}}{{end}}`),
+		file("templates/bye.tmpl", `{{define "bye"}}Bye, {{.}}{{end}}`),
+		file("static/css/site.css", "body{}"),
+		entry{hdr: tar.Header{Name: "static/latest.css", Typeflag: tar.TypeSymlink, Linkname: "css/site.css"}},
+		entry{hdr: tar.Header{Name: "home.html", Typeflag: tar.TypeLink, Linkname: "index.html"}},
+		entry{hdr: tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "../outside"}},
+	)
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestFS(t *testing.T) {
+	fsys, err := tarutil.OpenFS(buildSiteTar(t))
+	if err != nil {
+		t.Fatalf("OpenFS: %v", err)
+	}
+
+	b, err := fs.ReadFile(fsys, "static/latest.css")
+	if err != nil || string(b) != "body{}" {
+		t.Errorf("ReadFile(symlink) = %q, %v", b, err)
+	}
+	b, err = fs.ReadFile(fsys, "home.html")
+	if err != nil || string(b) != "<h1>index</h1>" {
+		t.Errorf("ReadFile(hardlink) = %q, %v", b, err)
+	}
+	if _, err := fsys.Open("escape"); err == nil {
+		t.Errorf("Open(escape) should fail")
+	}
+
+	// static 目录在 tar 中没有条目，由子条目推断
+	fi, err := fsys.Stat("static")
+	if err != nil || !fi.IsDir() {
+		t.Errorf("Stat(static) = %v, %v", fi, err)
+	}
+
+	var walked []string
+	_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		walked = append(walked, name)
+		return err
+	})
+	want := ".,escape,home.html,index.html,static,static/css,static/css/site.css,static/latest.css,templates,templates/bye.tmpl,templates/hello.tmpl"
+	if got := strings.Join(walked, ","); got != want {
+		t.Errorf("WalkDir = %s\nwant %s", got, want)
+	}
+}
+
+// TestFSConformance 使用 fstest.TestFS 检查 fs.FS 的各个方法是否一致
+func TestFSConformance(t *testing.T) {
+	buf := buildTar(t,
+		file("a.txt", "a"),
+		file("dir/b.txt", "b"),
+		file("dir/sub/c.txt", "c"),
+	)
+	fsys, err := tarutil.OpenFS(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("OpenFS: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFSTemplate(t *testing.T) {
+	fsys, _ := tarutil.OpenFS(buildSiteTar(t))
+	tmpl, err := template.ParseFS(fsys, "templates/*.tmpl")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	var buf bytes.Buffer
+	_ = tmpl.ExecuteTemplate(&buf, "hello", "gopher")
+	if buf.String() != "Hello, gopher" {
+		t.Errorf("template output = %q", buf.String())
+	}
+}
+
+func TestFSHTTP(t *testing.T) {
+	fsys, _ := tarutil.OpenFS(buildSiteTar(t))
+	server := httptest.NewServer(http.FileServer(http.FS(fsys)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/static/css/site.css")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if string(b) != "body{}" || resp.Header.Get("Content-Type") != "text/css; charset=utf-8" {
+		t.Errorf("GET site.css = %q, %s", b, resp.Header.Get("Content-Type"))
+	}
+}
+
+// TestIndexRoundTrip 保存的索引可以在不扫描 tar 的情况下打开文件系统
+func TestIndexRoundTrip(t *testing.T) {
+	r := buildSiteTar(t)
+	idx, err := tarutil.BuildIndex(r)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+
+	var saved bytes.Buffer
+	if _, err := idx.WriteTo(&saved); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	loaded, err := tarutil.ReadIndex(&saved)
+	if err != nil {
+		t.Fatalf("ReadIndex: %v", err)
+	}
+
+	b, err := fs.ReadFile(tarutil.NewFS(r, loaded), "templates/bye.tmpl")
+	if err != nil || !strings.Contains(string(b), "Bye") {
+		t.Errorf("ReadFile = %q, %v", b, err)
+	}
+}
+
+// TestIndexOffset tar 不在文件开头时，索引中的偏移是文件中的绝对位置，NewFS 可以直接读取同一个文件
+func TestIndexOffset(t *testing.T) {
+	site := buildSiteTar(t)
+	prefix := strings.Repeat("x", 1000)
+	data := make([]byte, len(prefix)+int(site.Size()))
+	copy(data, prefix)
+	if _, err := site.ReadAt(data[len(prefix):], 0); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bytes.NewReader(data)
+	if _, err := r.Seek(int64(len(prefix)), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := tarutil.OpenFS(r)
+	if err != nil {
+		t.Fatalf("OpenFS: %v", err)
+	}
+	for name, want := range map[string]string{
+		"index.html":          "<h1>index</h1>",
+		"static/css/site.css": "body{}",
+	} {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil || string(b) != want {
+			t.Errorf("ReadFile(%s) = %q, %v", name, b, err)
+		}
+	}
+}
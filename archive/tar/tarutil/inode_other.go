@@ -0,0 +1,10 @@
+//go:build !unix
+
+package tarutil
+
+import "io/fs"
+
+// inodeKey 在不支持 inode 的平台上不识别硬链接，所有文件都保存完整内容
+func inodeKey(fs.FileInfo) (any, bool) {
+	return nil, false
+}
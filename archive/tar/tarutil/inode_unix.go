@@ -0,0 +1,22 @@
+//go:build unix
+
+package tarutil
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// inode 唯一标识文件系统中的一个文件
+type inode struct {
+	dev, ino uint64
+}
+
+// inodeKey 返回有多个硬链接的文件的 inode，用于识别同一个文件的多个名字
+func inodeKey(fi fs.FileInfo) (any, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink < 2 {
+		return nil, false
+	}
+	return inode{uint64(st.Dev), uint64(st.Ino)}, true
+}
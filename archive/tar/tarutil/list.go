@@ -0,0 +1,187 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Entry tar 中一个条目的信息
+type Entry struct {
+	Name     string    `json:"name"`
+	Type     string    `json:"type"`
+	Mode     string    `json:"mode"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mtime"`
+	Linkname string    `json:"link,omitempty"`
+	SHA256   string    `json:"sha256,omitempty"`
+}
+
+// typeNames Typeflag 对应的名称
+var typeNames = map[byte]string{
+	tar.TypeReg:     "file",
+	tar.TypeRegA:    "file",
+	tar.TypeLink:    "hardlink",
+	tar.TypeSymlink: "symlink",
+	tar.TypeChar:    "char",
+	tar.TypeBlock:   "block",
+	tar.TypeDir:     "dir",
+	tar.TypeFifo:    "fifo",
+}
+
+// List 返回 r 中所有条目的信息，r 可以是 NewReader 支持的任意压缩格式
+//
+// hash 为 true 时计算普通文件内容的 SHA-256
+func List(r io.Reader, hash bool) ([]Entry, error) {
+	tr, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer tr.Close()
+
+	var entries []Entry
+	err = tr.Walk(func(hdr *tar.Header, body io.Reader) error {
+		e := Entry{
+			Name:     hdr.Name,
+			Type:     typeNames[hdr.Typeflag],
+			Mode:     hdr.FileInfo().Mode().String(),
+			Size:     hdr.Size,
+			ModTime:  hdr.ModTime,
+			Linkname: hdr.Linkname,
+		}
+		if e.Type == "" {
+			e.Type = string(hdr.Typeflag)
+		}
+		if hash && e.Type == "file" {
+			h := sha256.New()
+			if _, err := io.Copy(h, body); err != nil {
+				return err
+			}
+			e.SHA256 = hex.EncodeToString(h.Sum(nil))
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+// WriteTable 使用 text/tabwriter 输出对齐的表格
+func WriteTable(w io.Writer, entries []Entry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODE\tTYPE\tSIZE\tMODIFIED\tNAME")
+	for _, e := range entries {
+		name := e.Name
+		if e.Linkname != "" {
+			name += " -> " + e.Linkname
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", e.Mode, e.Type, e.Size, e.ModTime.Format(time.DateTime), name)
+	}
+	return tw.Flush()
+}
+
+// ChangeKind 变化的类型
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+)
+
+// Change 两个 tar 之间一个条目的变化
+type Change struct {
+	Kind   ChangeKind `json:"kind"`
+	Name   string     `json:"name"`
+	Fields []string   `json:"fields,omitempty"` // Modified 时发生变化的字段
+	Old    *Entry     `json:"old,omitempty"`
+	New    *Entry     `json:"new,omitempty"`
+}
+
+// DiffOptions 比较时的选项
+type DiffOptions struct {
+	IgnoreModTime bool // 不比较修改时间，用于比较不同时间构建的产物
+}
+
+// Diff 比较两组条目，按名称排序返回新增、删除和修改的条目
+//
+// 两边都计算了 SHA-256 时才比较内容，否则只比较元数据
+func Diff(older, newer []Entry, opts *DiffOptions) []Change {
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+	oldByName := make(map[string]*Entry, len(older))
+	for i := range older {
+		oldByName[older[i].Name] = &older[i]
+	}
+	newByName := make(map[string]*Entry, len(newer))
+	for i := range newer {
+		newByName[newer[i].Name] = &newer[i]
+	}
+
+	var changes []Change
+	for name, o := range oldByName {
+		n, ok := newByName[name]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Name: name, Old: o})
+			continue
+		}
+		if fields := diffFields(o, n, opts); len(fields) > 0 {
+			changes = append(changes, Change{Kind: Modified, Name: name, Fields: fields, Old: o, New: n})
+		}
+	}
+	for name, n := range newByName {
+		if _, ok := oldByName[name]; !ok {
+			changes = append(changes, Change{Kind: Added, Name: name, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// diffFields 返回 o 和 n 之间不同的字段名
+func diffFields(o, n *Entry, opts *DiffOptions) []string {
+	var fields []string
+	if o.Type != n.Type {
+		fields = append(fields, "type")
+	}
+	if o.Mode != n.Mode {
+		fields = append(fields, "mode")
+	}
+	if o.Size != n.Size {
+		fields = append(fields, "size")
+	}
+	if !opts.IgnoreModTime && !o.ModTime.Equal(n.ModTime) {
+		fields = append(fields, "mtime")
+	}
+	if o.Linkname != n.Linkname {
+		fields = append(fields, "link")
+	}
+	if o.SHA256 != "" && n.SHA256 != "" && o.SHA256 != n.SHA256 {
+		fields = append(fields, "content")
+	}
+	return fields
+}
+
+// WriteDiffTable 使用 text/tabwriter 输出变化
+func WriteDiffTable(w io.Writer, changes []Change) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGE\tNAME\tDETAIL")
+	for _, c := range changes {
+		detail := ""
+		switch c.Kind {
+		case Added:
+			detail = fmt.Sprintf("%s %s %d", c.New.Type, c.New.Mode, c.New.Size)
+		case Removed:
+			detail = fmt.Sprintf("%s %s %d", c.Old.Type, c.Old.Mode, c.Old.Size)
+		case Modified:
+			detail = fmt.Sprint(c.Fields)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Kind, c.Name, detail)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,78 @@
+package tarutil_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+func TestList(t *testing.T) {
+	buf := buildTar(t,
+		entry{hdr: tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		entry{tar.Header{Name: "bin/app", Typeflag: tar.TypeReg, Mode: 0o755}, "binary"},
+		entry{hdr: tar.Header{Name: "app", Typeflag: tar.TypeSymlink, Linkname: "bin/app"}},
+	)
+	entries, err := tarutil.List(buf, true)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("len(entries) = %d, want 3", len(entries))
+	}
+	if e := entries[1]; e.Type != "file" || e.Mode != "-rwxr-xr-x" || e.Size != 6 || e.SHA256 == "" {
+		t.Errorf("entries[1] = %+v", e)
+	}
+
+	var out bytes.Buffer
+	if err := tarutil.WriteTable(&out, entries); err != nil {
+		t.Fatalf("WriteTable: %v", err)
+	}
+	t.Logf("\n%s", out.String())
+	if !strings.Contains(out.String(), "app -> bin/app") {
+		t.Errorf("table does not show link target")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	mtime := time.Unix(1e9, 0)
+	older, _ := tarutil.List(buildTar(t,
+		entry{tar.Header{Name: "same", Typeflag: tar.TypeReg, ModTime: mtime}, "same"},
+		entry{tar.Header{Name: "content", Typeflag: tar.TypeReg, ModTime: mtime}, "v1"},
+		entry{tar.Header{Name: "mode", Typeflag: tar.TypeReg, Mode: 0o644, ModTime: mtime}, "x"},
+		entry{tar.Header{Name: "removed", Typeflag: tar.TypeReg, ModTime: mtime}, "gone"},
+	), true)
+	newer, _ := tarutil.List(buildTar(t,
+		entry{tar.Header{Name: "added", Typeflag: tar.TypeReg, ModTime: mtime}, "new"},
+		entry{tar.Header{Name: "content", Typeflag: tar.TypeReg, ModTime: mtime}, "v2"},
+		entry{tar.Header{Name: "mode", Typeflag: tar.TypeReg, Mode: 0o755, ModTime: mtime.Add(time.Hour)}, "x"},
+		entry{tar.Header{Name: "same", Typeflag: tar.TypeReg, ModTime: mtime}, "same"},
+	), true)
+
+	changes := tarutil.Diff(older, newer, nil)
+	var got []string
+	for _, c := range changes {
+		got = append(got, string(c.Kind)+" "+c.Name+" "+strings.Join(c.Fields, "+"))
+	}
+	want := "added added ,modified content content,modified mode mode+mtime,removed removed "
+	if s := strings.Join(got, ","); s != want {
+		t.Errorf("Diff = %q\nwant %q", s, want)
+	}
+
+	changes = tarutil.Diff(older, newer, &tarutil.DiffOptions{IgnoreModTime: true})
+	for _, c := range changes {
+		if c.Name == "mode" && strings.Join(c.Fields, "+") != "mode" {
+			t.Errorf("IgnoreModTime: fields = %v", c.Fields)
+		}
+	}
+	if len(tarutil.Diff(older, older, nil)) != 0 {
+		t.Errorf("Diff(older, older) should be empty")
+	}
+
+	var out bytes.Buffer
+	_ = tarutil.WriteDiffTable(&out, changes)
+	t.Logf("\n%s", out.String())
+}
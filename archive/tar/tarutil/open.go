@@ -0,0 +1,251 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Compression tar 数据外层的压缩格式
+type Compression int
+
+const (
+	None Compression = iota
+	Gzip
+	Bzip2
+	Zlib
+)
+
+func (c Compression) String() string {
+	switch c {
+	case None:
+		return "none"
+	case Gzip:
+		return "gzip"
+	case Bzip2:
+		return "bzip2"
+	case Zlib:
+		return "zlib"
+	}
+	return "Compression(" + strconv.Itoa(int(c)) + ")"
+}
+
+// FormatError 不支持的格式，Format 为识别出的格式名称，无法识别时为 "unknown"
+type FormatError struct {
+	Format string
+}
+
+func (e *FormatError) Error() string {
+	return "tarutil: unsupported archive format: " + e.Format
+}
+
+// blockSize tar 头部的大小
+const blockSize = 512
+
+// signatures 可以识别但是不支持的格式
+var signatures = []struct {
+	format string
+	magic  []byte
+}{
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{"lz4", []byte{0x04, 0x22, 0x4d, 0x18}},
+	{"zip", []byte("PK\x03\x04")},
+	{"7z", []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}},
+}
+
+// Detect 根据开头的魔数判断 r 中数据的压缩格式，不会消耗 r 中的数据
+//
+// 返回 None 表示数据是未压缩的 tar，空数据同样返回 None。
+// 其他格式返回 *FormatError。
+func Detect(r *bufio.Reader) (Compression, error) {
+	head, err := r.Peek(blockSize)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return None, err
+	}
+	switch {
+	case len(head) == 0:
+		return None, nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return Gzip, nil
+	case bytes.HasPrefix(head, []byte("BZh")):
+		return Bzip2, nil
+	case isTar(head):
+		// 需要在 zlib 之前判断，文件名以 'x' 开头的 tar 可能恰好满足 zlib 的校验规则
+		return None, nil
+	case len(head) >= 2 && head[0]&0x0f == 8 && head[0]>>4 <= 7 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0:
+		return Zlib, nil
+	}
+	for _, sig := range signatures {
+		if bytes.HasPrefix(head, sig.magic) {
+			return None, &FormatError{Format: sig.format}
+		}
+	}
+	return None, &FormatError{Format: "unknown"}
+}
+
+// isTar 判断 block 是否为 tar 头部：POSIX 格式在偏移 257 处有 "ustar" 魔数，
+// 更早的 V7 格式没有魔数，只能通过头部校验和判断。
+// 全零的块是 tar 的结束标记，没有任何条目的 tar 只包含两个全零的块，同样当作 tar。
+func isTar(block []byte) bool {
+	if len(block) < blockSize {
+		return false
+	}
+	if bytes.Equal(block[257:262], []byte("ustar")) || allZero(block[:blockSize]) {
+		return true
+	}
+
+	// 校验和字段位于 148~156，计算时按 8 个空格处理
+	field := bytes.Trim(block[148:156], " \x00")
+	want, err := strconv.ParseInt(string(field), 8, 64)
+	if err != nil {
+		return false
+	}
+	var sum int64
+	for i, b := range block {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+		sum += int64(b)
+	}
+	return sum == want
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader 自动解压的 tar 读取器，通过内嵌的 tar.Reader 的 Next 和 Read 遍历条目
+type Reader struct {
+	*tar.Reader
+	Compression Compression
+	closer      io.Closer
+}
+
+// NewReader 识别 r 的压缩格式（gzip、bzip2、zlib 或者未压缩），返回读取其中 tar 条目的 Reader
+//
+// 不支持的格式返回 *FormatError，例如 xz 压缩的 tar 返回的错误信息为
+// "tarutil: unsupported archive format: xz"
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReaderSize(r, blockSize*2)
+	c, err := Detect(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		inner  io.Reader = br
+		closer io.Closer
+	)
+	switch c {
+	case Gzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		inner, closer = zr, zr
+	case Bzip2:
+		inner = bzip2.NewReader(br)
+	case Zlib:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		inner, closer = zr, zr
+	}
+
+	if c != None {
+		// 解压后的数据必须是 tar，例如 gzip 压缩的 zip 文件同样不支持
+		ibr := bufio.NewReaderSize(inner, blockSize*2)
+		ic, err := Detect(ibr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c, err)
+		}
+		if ic != None {
+			return nil, &FormatError{Format: ic.String() + " inside " + c.String()}
+		}
+		inner = ibr
+	}
+	return &Reader{Reader: tar.NewReader(inner), Compression: c, closer: closer}, nil
+}
+
+// Walk 依次对每个条目调用 fn，body 只在 fn 执行期间有效
+func (r *Reader) Walk(fn func(hdr *tar.Header, body io.Reader) error) error {
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(hdr, r); err != nil {
+			return err
+		}
+	}
+}
+
+// Close 关闭解压器，不会关闭传给 NewReader 的 io.Reader
+func (r *Reader) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// Writer 写入压缩的 tar，Close 会依次关闭 tar.Writer 和压缩器
+type Writer struct {
+	*tar.Writer
+	Compression Compression
+	zw          io.WriteCloser
+}
+
+// NewWriter 返回写入 w 的 tar.Writer，数据使用 c 指定的格式压缩，level 为压缩级别，例如 gzip.BestCompression
+//
+// 标准库不支持 bzip2 压缩，c 为 Bzip2 时返回 *FormatError
+func NewWriter(w io.Writer, c Compression, level int) (*Writer, error) {
+	var zw io.WriteCloser
+	var err error
+	switch c {
+	case None:
+		return &Writer{Writer: tar.NewWriter(w), Compression: c}, nil
+	case Gzip:
+		zw, err = gzip.NewWriterLevel(w, level)
+	case Zlib:
+		zw, err = zlib.NewWriterLevel(w, level)
+	default:
+		return nil, &FormatError{Format: c.String() + " (write)"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{Writer: tar.NewWriter(zw), Compression: c, zw: zw}, nil
+}
+
+// NewGzipWriter 返回写入 tar.gz 的 Writer
+func NewGzipWriter(w io.Writer, level int) (*Writer, error) {
+	return NewWriter(w, Gzip, level)
+}
+
+// Close 写入 tar 的结束标记并刷新压缩器，不会关闭 w
+func (w *Writer) Close() error {
+	if err := w.Writer.Close(); err != nil {
+		return err
+	}
+	if w.zw != nil {
+		return w.zw.Close()
+	}
+	return nil
+}
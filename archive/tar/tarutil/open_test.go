@@ -0,0 +1,168 @@
+package tarutil_test
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+func TestNewReader(t *testing.T) {
+	plain := buildTar(t, file("a.txt", "hello"), file("b.txt", "world")).Bytes()
+
+	var gz, zl bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(plain)
+	_ = gw.Close()
+	zw := zlib.NewWriter(&zl)
+	_, _ = zw.Write(plain)
+	_ = zw.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+		want tarutil.Compression
+	}{
+		{"tar", plain, tarutil.None},
+		{"tar.gz", gz.Bytes(), tarutil.Gzip},
+		{"tar.zlib", zl.Bytes(), tarutil.Zlib},
+	}
+	for _, tt := range tests {
+		r, err := tarutil.NewReader(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Fatalf("%s: NewReader: %v", tt.name, err)
+		}
+		if r.Compression != tt.want {
+			t.Errorf("%s: Compression = %v, want %v", tt.name, r.Compression, tt.want)
+		}
+
+		var got []string
+		err = r.Walk(func(hdr *tar.Header, body io.Reader) error {
+			b, err := io.ReadAll(body)
+			got = append(got, hdr.Name+"="+string(b))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s: Walk: %v", tt.name, err)
+		}
+		if s := strings.Join(got, ","); s != "a.txt=hello,b.txt=world" {
+			t.Errorf("%s: entries = %s", tt.name, s)
+		}
+		_ = r.Close()
+	}
+}
+
+func TestNewReaderBzip2(t *testing.T) {
+	f, err := os.Open("../testdata/hdr-only.tar.bz2")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	r, err := tarutil.NewReader(f)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if r.Compression != tarutil.Bzip2 {
+		t.Errorf("Compression = %v, want bzip2", r.Compression)
+	}
+	n := 0
+	if err := r.Walk(func(*tar.Header, io.Reader) error { n++; return nil }); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if n == 0 {
+		t.Errorf("no entries")
+	}
+}
+
+func TestNewReaderUnsupported(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte("PK\x03\x04 not a tar"))
+	_ = gw.Close()
+
+	tests := []struct {
+		data   []byte
+		format string
+	}{
+		{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, "xz"},
+		{[]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, "zstd"},
+		{[]byte("just some text"), "unknown"},
+		{gz.Bytes(), "zip"},
+	}
+	for _, tt := range tests {
+		_, err := tarutil.NewReader(bytes.NewReader(tt.data))
+		var fe *tarutil.FormatError
+		if !errors.As(err, &fe) || fe.Format != tt.format {
+			t.Errorf("NewReader(%q) err = %v, want format %q", tt.data, err, tt.format)
+		}
+	}
+}
+
+func TestDetectEmpty(t *testing.T) {
+	c, err := tarutil.Detect(bufio.NewReader(bytes.NewReader(nil)))
+	if c != tarutil.None || err != nil {
+		t.Errorf("Detect(empty) = %v, %v", c, err)
+	}
+}
+
+// TestEmptyArchive 没有任何条目的 tar 只包含两个全零的块，压缩与否都可以读回
+func TestEmptyArchive(t *testing.T) {
+	for _, c := range []tarutil.Compression{tarutil.None, tarutil.Gzip, tarutil.Zlib} {
+		var buf bytes.Buffer
+		w, err := tarutil.NewWriter(&buf, c, gzip.DefaultCompression)
+		if err != nil {
+			t.Fatalf("%s: NewWriter: %v", c, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close: %v", c, err)
+		}
+
+		r, err := tarutil.NewReader(&buf)
+		if err != nil {
+			t.Fatalf("%s: NewReader: %v", c, err)
+		}
+		if r.Compression != c {
+			t.Errorf("%s: Compression = %v", c, r.Compression)
+		}
+		if hdr, err := r.Next(); err != io.EOF {
+			t.Errorf("%s: Next = %v, %v, want io.EOF", c, hdr, err)
+		}
+		_ = r.Close()
+	}
+}
+
+func TestNewGzipWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := tarutil.NewGzipWriter(&buf, gzip.BestCompression)
+	if err != nil {
+		t.Fatalf("NewGzipWriter: %v", err)
+	}
+	body := strings.Repeat("gopher ", 100)
+	_ = w.WriteHeader(&tar.Header{Name: "g.txt", Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg})
+	_, _ = w.Write([]byte(body))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := tarutil.NewReader(&buf)
+	if err != nil || r.Compression != tarutil.Gzip {
+		t.Fatalf("NewReader = %v, %v", r, err)
+	}
+	hdr, err := r.Next()
+	if err != nil || hdr.Name != "g.txt" {
+		t.Fatalf("Next = %v, %v", hdr, err)
+	}
+
+	if _, err := tarutil.NewWriter(&buf, tarutil.Bzip2, 9); err == nil {
+		t.Errorf("NewWriter(Bzip2) should fail")
+	}
+}
@@ -0,0 +1,187 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrSkip 由 Hook 返回，表示丢弃当前条目
+var ErrSkip = errors.New("tarutil: skip entry")
+
+// Hook 在条目写入之前调用，可以直接修改 hdr
+//
+// 返回的 io.Reader 作为新的内容写入，通常直接返回 body；
+// 替换内容时必须同时把 hdr.Size 设置为新内容的长度。
+// 返回的 io.Reader 实现了 io.Closer 时，Rewrite 在处理完该条目之后关闭它，
+// 条目被丢弃或者出错时同样会关闭。
+// 返回 ErrSkip（或者包装了它的错误）丢弃该条目，返回其他错误会中止 Rewrite。
+type Hook func(hdr *tar.Header, body io.Reader) (io.Reader, error)
+
+// Rewrite 从 tr 读取条目，依次经过 hooks 处理后写入 tw
+//
+// 数据以流的方式从 tr 复制到 tw，不会写入磁盘，也不会把整个文件读入内存。
+// Rewrite 不会关闭 tw。被丢弃的条目如果是硬链接的目标，指向它的硬链接同样需要处理。
+func Rewrite(tw *tar.Writer, tr *tar.Reader, hooks ...Hook) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := rewriteEntry(tw, tr, hdr, hooks); err != nil {
+			return err
+		}
+	}
+}
+
+// rewriteEntry 使用 hooks 处理一个条目并写入 tw，关闭 hooks 返回的所有 io.Closer
+func rewriteEntry(tw *tar.Writer, tr *tar.Reader, hdr *tar.Header, hooks []Hook) (err error) {
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			if cerr := c.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("tarutil: rewrite %s: %w", hdr.Name, cerr)
+			}
+		}
+	}()
+
+	var body io.Reader = tr
+	for _, hook := range hooks {
+		next, err := hook(hdr, body)
+		if c, ok := next.(io.Closer); ok && !same(next, body) {
+			closers = append(closers, c)
+		}
+		if errors.Is(err, ErrSkip) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("tarutil: rewrite %s: %w", hdr.Name, err)
+		}
+		body = next
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if hdr.Size == 0 || !isRegular(hdr) {
+		return nil
+	}
+	n, err := io.Copy(tw, body)
+	if err != nil {
+		return fmt.Errorf("tarutil: rewrite %s: %w", hdr.Name, err)
+	}
+	if n != hdr.Size {
+		return fmt.Errorf("tarutil: rewrite %s: wrote %d bytes, header size is %d", hdr.Name, n, hdr.Size)
+	}
+	return nil
+}
+
+// same 判断 hook 是否原样返回了 body，不可比较的类型按照不同处理，避免 == 引发 panic
+func same(a, b io.Reader) bool {
+	t := reflect.TypeOf(a)
+	return t != nil && t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+func isRegular(hdr *tar.Header) bool {
+	return hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA
+}
+
+// Filter 只保留 keep 返回 true 的条目
+func Filter(keep func(hdr *tar.Header) bool) Hook {
+	return func(hdr *tar.Header, body io.Reader) (io.Reader, error) {
+		if !keep(hdr) {
+			return nil, ErrSkip
+		}
+		return body, nil
+	}
+}
+
+// Rename 使用 rename 修改条目的路径，硬链接的目标会一起修改
+//
+// rename 返回空字符串时丢弃该条目；硬链接的目标被改为空字符串时返回错误，
+// 这通常说明链接指向的条目已经被丢弃，写出的硬链接将无法解包。
+func Rename(rename func(name string) string) Hook {
+	return func(hdr *tar.Header, body io.Reader) (io.Reader, error) {
+		dir := strings.HasSuffix(hdr.Name, "/")
+		name := rename(strings.TrimSuffix(hdr.Name, "/"))
+		if name == "" {
+			return nil, ErrSkip
+		}
+		if dir {
+			name += "/"
+		}
+		hdr.Name = name
+		if hdr.Typeflag == tar.TypeLink {
+			link := rename(hdr.Linkname)
+			if link == "" {
+				return nil, fmt.Errorf("hardlink target %s renamed to an empty name", hdr.Linkname)
+			}
+			hdr.Linkname = link
+		}
+		return body, nil
+	}
+}
+
+// StripPrefix 去掉路径的前缀，例如 GitHub 下载的源码包中的 "project-1.0/"，去掉后为空的条目会被丢弃
+func StripPrefix(prefix string) Hook {
+	dir := strings.TrimSuffix(prefix, "/")
+	return Rename(func(name string) string {
+		if name == dir {
+			return ""
+		}
+		return strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+	})
+}
+
+// SetOwner 把所有者设置为 uid/gid，并清空用户名和组名
+func SetOwner(uid, gid int) Hook {
+	return func(hdr *tar.Header, body io.Reader) (io.Reader, error) {
+		hdr.Uid, hdr.Gid = uid, gid
+		hdr.Uname, hdr.Gname = "", ""
+		return body, nil
+	}
+}
+
+// SetMode 使用 mode 计算新的权限，mode 接收和返回的都是 Header.Mode 中的权限位
+func SetMode(mode func(hdr *tar.Header) fs.FileMode) Hook {
+	return func(hdr *tar.Header, body io.Reader) (io.Reader, error) {
+		hdr.Mode = hdr.Mode&^0o7777 | int64(mode(hdr)&0o7777)
+		return body, nil
+	}
+}
+
+// SetModTime 把所有条目的修改时间设置为 mtime，并清空访问时间和状态改变时间
+func SetModTime(mtime time.Time) Hook {
+	return func(hdr *tar.Header, body io.Reader) (io.Reader, error) {
+		hdr.ModTime = mtime
+		hdr.AccessTime, hdr.ChangeTime = time.Time{}, time.Time{}
+		return body, nil
+	}
+}
+
+// Replace 把名称为 name 的普通文件的内容替换为 open 返回的数据，size 为新内容的长度
+//
+// open 只在匹配的条目上调用，返回的 io.ReadCloser 可以是文件或者网络连接，内容以流的方式写入，
+// 写入之后由 Rewrite 关闭
+func Replace(name string, size int64, open func() (io.ReadCloser, error)) Hook {
+	return func(hdr *tar.Header, body io.Reader) (io.Reader, error) {
+		if hdr.Name != name || !isRegular(hdr) {
+			return body, nil
+		}
+		r, err := open()
+		if err != nil {
+			return nil, err
+		}
+		hdr.Size = size
+		return r, nil
+	}
+}
@@ -0,0 +1,145 @@
+package tarutil_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"standard-library-examples/archive/tar/tarutil"
+)
+
+func TestRewrite(t *testing.T) {
+	src := buildTar(t,
+		entry{hdr: tar.Header{Name: "app-1.0/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		entry{tar.Header{Name: "app-1.0/bin/app", Typeflag: tar.TypeReg, Mode: 0o700, Uid: 1000, Uname: "dev"}, "binary"},
+		file("app-1.0/config.yaml", "debug: true\n"),
+		file("app-1.0/.git/HEAD", "ref: refs/heads/main\n"),
+		entry{hdr: tar.Header{Name: "app-1.0/bin/app2", Typeflag: tar.TypeLink, Linkname: "app-1.0/bin/app"}},
+	)
+
+	config := "debug: false\n"
+	mtime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var dst bytes.Buffer
+	tw := tar.NewWriter(&dst)
+	err := tarutil.Rewrite(tw, tar.NewReader(src),
+		tarutil.Filter(func(hdr *tar.Header) bool { return !strings.Contains(hdr.Name, "/.git/") }),
+		tarutil.StripPrefix("app-1.0/"),
+		tarutil.SetOwner(0, 0),
+		tarutil.SetMode(func(hdr *tar.Header) fs.FileMode {
+			if hdr.Typeflag == tar.TypeDir || strings.HasPrefix(hdr.Name, "bin/") {
+				return 0o755
+			}
+			return 0o644
+		}),
+		tarutil.SetModTime(mtime),
+		tarutil.Replace("config.yaml", int64(len(config)), func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(config)), nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Rewrite: %v", err)
+	}
+	_ = tw.Close()
+
+	var got []string
+	tr := tar.NewReader(&dst)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		b, _ := io.ReadAll(tr)
+		if hdr.Uid != 0 || hdr.Uname != "" || !hdr.ModTime.Equal(mtime) {
+			t.Errorf("%s: uid = %d uname = %q mtime = %v", hdr.Name, hdr.Uid, hdr.Uname, hdr.ModTime)
+		}
+		got = append(got, hdr.Name+" "+fs.FileMode(hdr.Mode).String()+" "+hdr.Linkname+strings.TrimSpace(string(b)))
+	}
+	want := []string{
+		"bin/app -rwxr-xr-x binary",
+		"config.yaml -rw-r--r-- debug: false",
+		"bin/app2 -rwxr-xr-x bin/app",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestRewriteSizeMismatch 替换内容时 hdr.Size 与实际长度不一致会返回错误
+func TestRewriteSizeMismatch(t *testing.T) {
+	src := buildTar(t, file("a.txt", "hello"))
+	tw := tar.NewWriter(io.Discard)
+	err := tarutil.Rewrite(tw, tar.NewReader(src), tarutil.Replace("a.txt", 10, func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("short")), nil
+	}))
+	if err == nil {
+		t.Errorf("Rewrite should fail when the content is shorter than hdr.Size")
+	}
+}
+
+// closeCounter 记录 Close 的调用次数
+type closeCounter struct {
+	io.Reader
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+// TestReplaceClose Replace 打开的内容在写入之后关闭，条目被之后的 hook 丢弃时同样关闭
+func TestReplaceClose(t *testing.T) {
+	var opened []*closeCounter
+	replace := tarutil.Replace("a.txt", 3, func() (io.ReadCloser, error) {
+		c := &closeCounter{Reader: strings.NewReader("new")}
+		opened = append(opened, c)
+		return c, nil
+	})
+	skipB := func(hdr *tar.Header, body io.Reader) (io.Reader, error) {
+		if hdr.Name == "b.txt" {
+			return nil, fmt.Errorf("drop %s: %w", hdr.Name, tarutil.ErrSkip)
+		}
+		return body, nil
+	}
+
+	src := buildTar(t, file("a.txt", "old"), file("b.txt", "b"), file("a.txt", "old"))
+	var dst bytes.Buffer
+	tw := tar.NewWriter(&dst)
+	if err := tarutil.Rewrite(tw, tar.NewReader(src), replace, skipB); err != nil {
+		t.Fatalf("Rewrite: %v", err)
+	}
+	_ = tw.Close()
+	if len(opened) != 2 || opened[0].closed != 1 || opened[1].closed != 1 {
+		t.Errorf("opened %d readers, closed %v", len(opened), opened)
+	}
+
+	// 包装了 ErrSkip 的错误同样丢弃条目
+	tr := tar.NewReader(&dst)
+	var names []string
+	for hdr, err := tr.Next(); err == nil; hdr, err = tr.Next() {
+		names = append(names, hdr.Name)
+	}
+	if strings.Join(names, ",") != "a.txt,a.txt" {
+		t.Errorf("entries = %v", names)
+	}
+}
+
+// TestRenameEmptyLink 硬链接的目标被丢弃时返回错误，而不是写出 Linkname 为空的硬链接
+func TestRenameEmptyLink(t *testing.T) {
+	src := buildTar(t,
+		file("app/a.txt", "a"),
+		entry{hdr: tar.Header{Name: "app/b.txt", Typeflag: tar.TypeLink, Linkname: "app"}},
+	)
+	err := tarutil.Rewrite(tar.NewWriter(io.Discard), tar.NewReader(src), tarutil.StripPrefix("app/"))
+	if err == nil || !strings.Contains(err.Error(), "empty name") {
+		t.Errorf("Rewrite err = %v, want empty hardlink target error", err)
+	}
+}
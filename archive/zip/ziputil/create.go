@@ -0,0 +1,168 @@
+package ziputil
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"standard-library-examples/archive/internal/linkfs"
+)
+
+// DefaultStoreExts 已经压缩过的文件格式，再次压缩几乎不能减小体积，直接使用 Store 保存
+var DefaultStoreExts = []string{
+	".zip", ".jar", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".7z", ".rar",
+	".png", ".jpg", ".jpeg", ".gif", ".webp",
+	".mp3", ".mp4", ".mkv", ".mov", ".ogg",
+	".woff", ".woff2",
+}
+
+// CreateOptions 打包时的选项
+type CreateOptions struct {
+	// Level 不为 nil 时是 Deflate 的压缩级别，取值与 compress/flate 相同，包括 flate.NoCompression；
+	// nil 表示 flate.DefaultCompression。完全不压缩的文件请通过 StoreExts 或 Method 指定为 Store
+	Level *int
+	// StoreExts 使用 Store 保存的扩展名（不区分大小写），nil 表示 DefaultStoreExts
+	StoreExts []string
+	// Method 不为 nil 时决定每个文件的压缩方法，优先于 StoreExts
+	Method func(name string, size int64) uint16
+	// Compressors 额外注册到 zip.Writer 上的压缩器，可以覆盖 Deflate，或者配合 Method 使用自定义的压缩方法
+	Compressors map[uint16]zip.Compressor
+	// Comment 压缩包的注释
+	Comment string
+	// ModTime 不为零值时，所有条目都使用该修改时间，使相同的目录树总是生成相同的字节
+	ModTime time.Time
+}
+
+// ReadLinkFS 可以读取符号链接目标的文件系统，只有实现了该接口的 fs.FS 才能打包符号链接
+type ReadLinkFS = linkfs.ReadLinkFS
+
+// Create 把目录 dir 打包为 zip 写入 w
+func Create(w io.Writer, dir string, opts *CreateOptions) error {
+	return CreateFS(w, linkfs.DirFS(dir), opts)
+}
+
+// CreateFS 把 fsys 中的文件按照字典序打包为 zip 写入 w，保留权限和修改时间
+//
+// Deflate 的压缩级别通过 zip.Writer.RegisterCompressor 注册在本次使用的 Writer 上，
+// 而不是使用全局的 zip.RegisterCompressor，因此不会影响其他 zip.Writer。
+//
+// fsys 没有实现 ReadLinkFS 时，指向文件的符号链接按照普通文件保存；
+// 指向目录的符号链接返回错误，因为 fs.WalkDir 不会进入其中，目录的内容会被悄悄丢掉。
+func CreateFS(w io.Writer, fsys fs.FS, opts *CreateOptions) error {
+	if opts == nil {
+		opts = &CreateOptions{}
+	}
+	zw := zip.NewWriter(w)
+	level := flate.DefaultCompression
+	if opts.Level != nil {
+		level = *opts.Level
+	}
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return err
+	}
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+	for method, comp := range opts.Compressors {
+		zw.RegisterCompressor(method, comp)
+	}
+	if opts.Comment != "" {
+		if err := zw.SetComment(opts.Comment); err != nil {
+			return err
+		}
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			if rl, ok := fsys.(ReadLinkFS); ok {
+				if link, err = rl.ReadLink(name); err != nil {
+					return err
+				}
+			} else if fi, err = fs.Stat(fsys, name); err != nil {
+				return err
+			} else if fi.IsDir() {
+				return fmt.Errorf("ziputil: %s is a symlink to a directory and fsys cannot read symlinks", name)
+			}
+		}
+		if !fi.IsDir() && !fi.Mode().IsRegular() && link == "" {
+			// zip 不能表示设备文件和 FIFO
+			return nil
+		}
+
+		fh, err := zip.FileInfoHeader(fi)
+		if err != nil {
+			return err
+		}
+		fh.Name = name
+		if !opts.ModTime.IsZero() {
+			fh.Modified = opts.ModTime
+		}
+		switch {
+		case fi.IsDir():
+			fh.Name += "/"
+			fh.Method = zip.Store
+		case link != "":
+			fh.Method = zip.Store
+		default:
+			fh.Method = opts.method(name, fi.Size())
+		}
+
+		fw, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		switch {
+		case fi.IsDir():
+			return nil
+		case link != "":
+			_, err = io.WriteString(fw, link)
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// method 返回 name 使用的压缩方法
+func (opts *CreateOptions) method(name string, size int64) uint16 {
+	if opts.Method != nil {
+		return opts.Method(name, size)
+	}
+	exts := opts.StoreExts
+	if exts == nil {
+		exts = DefaultStoreExts
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range exts {
+		if ext == e {
+			return zip.Store
+		}
+	}
+	if size == 0 {
+		return zip.Store
+	}
+	return zip.Deflate
+}
@@ -0,0 +1,179 @@
+package ziputil_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"standard-library-examples/archive/internal/archivetest"
+	"standard-library-examples/archive/zip/ziputil"
+)
+
+// writeTree 在 dir 中创建测试用的目录树
+func writeTree(t *testing.T, dir string, mtime time.Time) {
+	t.Helper()
+	archivetest.WriteTree(t, dir, map[string]archivetest.File{
+		"readme.txt":      {Body: strings.Repeat("This archive contains some text files.\n", 50)},
+		"img/gopher.png":  {Body: strings.Repeat("\x89PNG", 100)},
+		"bin/run.sh":      {Body: "#!/bin/sh\n", Mode: 0o755},
+		"data/empty.json": {},
+		"README":          {Link: "readme.txt"},
+	}, mtime)
+}
+
+func openZip(t *testing.T, b []byte) *zip.Reader {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	return r
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC)
+	writeTree(t, dir, mtime)
+
+	var buf bytes.Buffer
+	level := flate.BestCompression
+	if err := ziputil.Create(&buf, dir, &ziputil.CreateOptions{Comment: "build 42", Level: &level}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	r := openZip(t, buf.Bytes())
+	if r.Comment != "build 42" {
+		t.Errorf("Comment = %q", r.Comment)
+	}
+
+	var got []string
+	for _, f := range r.File {
+		method := map[uint16]string{zip.Store: "store", zip.Deflate: "deflate"}[f.Method]
+		got = append(got, f.Name+" "+method+" "+f.Mode().String())
+		// os.Chtimes 会跟随符号链接，无法设置链接本身的修改时间
+		if f.Mode()&fs.ModeSymlink == 0 && !f.Modified.Equal(mtime) {
+			t.Errorf("%s: Modified = %v, want %v", f.Name, f.Modified, mtime)
+		}
+	}
+	want := []string{
+		"README store Lrwxrwxrwx",
+		"bin/ store drwxr-xr-x",
+		"bin/run.sh deflate -rwxr-xr-x",
+		"data/ store drwxr-xr-x",
+		"data/empty.json store -rw-r--r--",
+		"img/ store drwxr-xr-x",
+		"img/gopher.png store -rw-r--r--",
+		"readme.txt deflate -rw-r--r--",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 解压后与原目录相同
+	out := t.TempDir()
+	if err := ziputil.Extract(r, out, nil); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(out, "README")); !strings.HasPrefix(string(b), "This archive") {
+		t.Errorf("README = %q", b)
+	}
+}
+
+// TestCreateDeterministic 指定 ModTime 后，修改时间不同的相同目录树生成相同的字节
+func TestCreateDeterministic(t *testing.T) {
+	var outputs [2]bytes.Buffer
+	for i := range outputs {
+		dir := t.TempDir()
+		writeTree(t, dir, time.Now().Add(time.Duration(i)*time.Hour))
+		opts := &ziputil.CreateOptions{ModTime: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)}
+		if err := ziputil.Create(&outputs[i], dir, opts); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if !bytes.Equal(outputs[0].Bytes(), outputs[1].Bytes()) {
+		t.Errorf("archives differ")
+	}
+}
+
+// TestCreateLevel Level 可以指定 flate.NoCompression，nil 表示默认的压缩级别
+func TestCreateLevel(t *testing.T) {
+	body := strings.Repeat("a", 10000)
+	fsys := fstest.MapFS{"a.txt": {Data: []byte(body), Mode: 0o644}}
+
+	size := func(level *int) uint64 {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := ziputil.CreateFS(&buf, fsys, &ziputil.CreateOptions{Level: level}); err != nil {
+			t.Fatalf("CreateFS: %v", err)
+		}
+		f := openZip(t, buf.Bytes()).File[0]
+		if f.Method != zip.Deflate {
+			t.Fatalf("Method = %d, want Deflate", f.Method)
+		}
+		return f.CompressedSize64
+	}
+	none := flate.NoCompression
+	if n := size(&none); n < uint64(len(body)) {
+		t.Errorf("NoCompression: compressed size %d < %d", n, len(body))
+	}
+	if n := size(nil); n >= uint64(len(body))/10 {
+		t.Errorf("default level: compressed size %d", n)
+	}
+	bad := 10
+	if err := ziputil.CreateFS(io.Discard, fsys, &ziputil.CreateOptions{Level: &bad}); err == nil {
+		t.Errorf("invalid level should fail")
+	}
+}
+
+// TestCreateCompressor 使用自定义的压缩器和压缩方法
+func TestCreateCompressor(t *testing.T) {
+	fsys := fstest.MapFS{"a.txt": {Data: []byte(strings.Repeat("a", 1000)), Mode: 0o644}}
+
+	var sizes []int
+	for _, level := range []int{flate.NoCompression, flate.BestCompression} {
+		var buf bytes.Buffer
+		calls := 0
+		opts := &ziputil.CreateOptions{
+			Compressors: map[uint16]zip.Compressor{
+				zip.Deflate: func(w io.Writer) (io.WriteCloser, error) {
+					calls++
+					return flate.NewWriter(w, level)
+				},
+			},
+		}
+		if err := ziputil.CreateFS(&buf, fsys, opts); err != nil {
+			t.Fatalf("CreateFS: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("custom compressor called %d times", calls)
+		}
+		sizes = append(sizes, buf.Len())
+	}
+	if sizes[0] <= sizes[1] {
+		t.Errorf("NoCompression size %d <= BestCompression size %d", sizes[0], sizes[1])
+	}
+}
+
+// TestCreateSymlinkDir fsys 不能读取符号链接时，指向目录的符号链接返回错误，而不是丢掉目录的内容
+func TestCreateSymlinkDir(t *testing.T) {
+	dir := t.TempDir()
+	archivetest.WriteTree(t, dir, map[string]archivetest.File{
+		"real/a.txt": {Body: "a"},
+		"link":       {Link: "real"},
+	}, time.Time{})
+
+	// 只保留 Open 方法，隐藏 os.DirFS 可能实现的 ReadLink
+	fsys := struct{ fs.FS }{os.DirFS(dir)}
+	err := ziputil.CreateFS(io.Discard, fsys, nil)
+	if err == nil || !strings.Contains(err.Error(), "link") {
+		t.Errorf("CreateFS err = %v, want symlink to directory error", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package ziputil 在 archive/zip 的基础上提供安全解压、打包、更新、校验和流式读取
+package ziputil
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"standard-library-examples/archive/internal/safepath"
+)
+
+// InsecurePathError 条目名称是绝对路径、包含 ".." 指向解压目录之外，或者在磁盘上经过了符号链接，可以用 errors.Is 与 zip.ErrInsecurePath 比较
+type InsecurePathError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InsecurePathError) Error() string {
+	return fmt.Sprintf("ziputil: insecure path %q: %s", e.Name, e.Reason)
+}
+
+func (e *InsecurePathError) Unwrap() error { return zip.ErrInsecurePath }
+
+// SymlinkError 符号链接指向解压目录之外，可以用 errors.Is 与 zip.ErrInsecurePath 比较
+type SymlinkError struct {
+	Name   string
+	Target string
+}
+
+func (e *SymlinkError) Error() string {
+	return fmt.Sprintf("ziputil: symlink %q -> %q escapes the destination", e.Name, e.Target)
+}
+
+func (e *SymlinkError) Unwrap() error { return zip.ErrInsecurePath }
+
+// RatioError 条目的压缩比超过 ExtractOptions.MaxRatio
+type RatioError struct {
+	Name  string
+	Ratio float64
+	Max   float64
+}
+
+func (e *RatioError) Error() string {
+	return fmt.Sprintf("ziputil: %q compression ratio %.0f exceeds %.0f", e.Name, e.Ratio, e.Max)
+}
+
+// SizeError 解压后的总大小超过 ExtractOptions.MaxTotalSize
+type SizeError struct {
+	Size uint64
+	Max  int64
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("ziputil: uncompressed size %d exceeds %d", e.Size, e.Max)
+}
+
+// CountError 条目数量超过 ExtractOptions.MaxFiles
+type CountError struct {
+	Count int
+	Max   int
+}
+
+func (e *CountError) Error() string {
+	return fmt.Sprintf("ziputil: %d entries exceeds %d", e.Count, e.Max)
+}
+
+// ratioThreshold 小于该大小的文件不检查压缩比，内容重复的小文件压缩比本来就很高
+const ratioThreshold = 64 << 10
+
+// ExtractOptions 解压时的限制，值为 0 的字段表示不限制
+type ExtractOptions struct {
+	MaxFiles     int     // 最多的条目数量
+	MaxTotalSize int64   // 解压后的总字节数上限
+	MaxRatio     float64 // 单个文件解压后与压缩后大小之比的上限，只检查不小于 64 KiB 的文件
+}
+
+// DefaultExtractOptions Extract 的 opts 为 nil 时使用的限制
+var DefaultExtractOptions = ExtractOptions{
+	MaxFiles:     10000,
+	MaxTotalSize: 1 << 30,
+	MaxRatio:     100,
+}
+
+// ExtractFile 打开 zip 文件 name 并解压到 dir
+func ExtractFile(name, dir string, opts *ExtractOptions) error {
+	rc, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return Extract(&rc.Reader, dir, opts)
+}
+
+// Extract 把 r 中的文件解压到 dir
+//
+// 解压之前先根据中央目录中声明的大小检查所有限制，任何条目违反限制都不会写入文件；
+// 解压过程中按照实际解压出的字节数再次检查，防止声明的大小与实际数据不一致。
+// 路径中经过符号链接的条目同样会被拒绝，避免借助先解压的链接写到 dir 之外；
+// 符号链接的目标按照磁盘上已经存在的链接逐级解析，已经作为符号链接存在的目录条目也会被拒绝。
+func Extract(r *zip.Reader, dir string, opts *ExtractOptions) error {
+	if opts == nil {
+		opts = &DefaultExtractOptions
+	}
+	if err := check(r, opts); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var budget int64 = -1
+	if opts.MaxTotalSize > 0 {
+		budget = opts.MaxTotalSize
+	}
+	for _, f := range r.File {
+		n, err := extractFile(f, dir, budget)
+		if err != nil {
+			return err
+		}
+		if budget >= 0 {
+			budget -= n
+		}
+	}
+	return nil
+}
+
+// check 根据中央目录检查路径和各项限制
+func check(r *zip.Reader, opts *ExtractOptions) error {
+	if opts.MaxFiles > 0 && len(r.File) > opts.MaxFiles {
+		return &CountError{Count: len(r.File), Max: opts.MaxFiles}
+	}
+	var total uint64
+	for _, f := range r.File {
+		if err := checkName(f.Name); err != nil {
+			return err
+		}
+		total += f.UncompressedSize64
+		if opts.MaxTotalSize > 0 && total > uint64(opts.MaxTotalSize) {
+			return &SizeError{Size: total, Max: opts.MaxTotalSize}
+		}
+		if opts.MaxRatio > 0 && f.UncompressedSize64 >= ratioThreshold {
+			ratio := float64(f.UncompressedSize64) / float64(f.CompressedSize64)
+			if f.CompressedSize64 == 0 || ratio > opts.MaxRatio {
+				return &RatioError{Name: f.Name, Ratio: ratio, Max: opts.MaxRatio}
+			}
+		}
+	}
+	return nil
+}
+
+// checkName 检查条目名称是否为解压目录之内的相对路径
+func checkName(name string) error {
+	if strings.Contains(name, `\`) {
+		return &InsecurePathError{Name: name, Reason: "contains backslash"}
+	}
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) {
+		return &InsecurePathError{Name: name, Reason: "absolute path"}
+	}
+	if !filepath.IsLocal(strings.TrimSuffix(name, "/")) {
+		return &InsecurePathError{Name: name, Reason: "escapes the destination"}
+	}
+	return nil
+}
+
+// extractFile 解压一个条目，budget 为剩余可以写入的字节数，-1 表示不限制，返回写入的字节数
+func extractFile(f *zip.File, dir string, budget int64) (int64, error) {
+	mode := f.Mode()
+	if mode.IsDir() {
+		target, err := safepath.Mkdir(dir, f.Name)
+		if err != nil {
+			return 0, insecure(err)
+		}
+		return 0, safepath.Chmod(target, mode.Perm()|0o700)
+	}
+
+	target, err := safepath.Path(dir, f.Name)
+	if err != nil {
+		return 0, insecure(err)
+	}
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		link, err := readLink(f)
+		if err != nil {
+			return 0, err
+		}
+		if err := safepath.CheckLink(dir, f.Name, link); err != nil {
+			var pe *safepath.Error
+			if errors.As(err, &pe) {
+				return 0, &SymlinkError{Name: f.Name, Target: link}
+			}
+			return 0, err
+		}
+		if err := safepath.Remove(target); err != nil {
+			return 0, err
+		}
+		return 0, os.Symlink(link, target)
+	case !mode.IsRegular():
+		// 设备文件、FIFO 等不解压
+		return 0, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	var src io.Reader = rc
+	if budget >= 0 {
+		// 多读一个字节，用来判断实际数据是否超出剩余的额度
+		src = io.LimitReader(rc, budget+1)
+	}
+	if err := safepath.Remove(target); err != nil {
+		return 0, err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, src)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, err
+	}
+	if budget >= 0 && n > budget {
+		_ = os.Remove(target)
+		return n, &SizeError{Size: uint64(n), Max: budget}
+	}
+	if err := os.Chmod(target, mode.Perm()); err != nil {
+		return n, err
+	}
+	return n, os.Chtimes(target, f.Modified, f.Modified)
+}
+
+// readLink 读取符号链接条目的内容，即链接的目标
+func readLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, 4096))
+	return string(b), err
+}
+
+// insecure 把 safepath 的错误转换为 *InsecurePathError
+func insecure(err error) error {
+	var pe *safepath.Error
+	if errors.As(err, &pe) {
+		return &InsecurePathError{Name: pe.Name, Reason: pe.Reason}
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+package ziputil_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"standard-library-examples/archive/zip/ziputil"
+)
+
+// zipEntry 测试中用来构造 zip 的条目
+type zipEntry struct {
+	name string
+	body []byte
+	mode fs.FileMode
+}
+
+// buildZip 在内存中构造 zip 数据
+func buildZip(t *testing.T, entries ...zipEntry) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)}
+		if e.mode != 0 {
+			fh.SetMode(e.mode)
+		}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatalf("CreateHeader(%s): %v", e.name, err)
+		}
+		if _, err := w.Write(e.body); err != nil {
+			t.Fatalf("Write(%s): %v", e.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	return r
+}
+
+func TestExtract(t *testing.T) {
+	r := buildZip(t,
+		zipEntry{name: "docs/", mode: fs.ModeDir | 0o755},
+		zipEntry{name: "docs/readme.txt", body: []byte("hello"), mode: 0o600},
+		zipEntry{name: "run.sh", body: []byte("#!/bin/sh"), mode: 0o755},
+		zipEntry{name: "docs/latest", body: []byte("readme.txt"), mode: fs.ModeSymlink | 0o777},
+	)
+	dir := t.TempDir()
+	if err := ziputil.Extract(r, dir, nil); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	fi, err := os.Stat(filepath.Join(dir, "run.sh"))
+	if err != nil || fi.Mode().Perm() != 0o755 {
+		t.Errorf("run.sh: %v, %v", fi, err)
+	}
+	fi, _ = os.Stat(filepath.Join(dir, "docs/readme.txt"))
+	if fi.Mode().Perm() != 0o600 || !fi.ModTime().Equal(time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)) {
+		t.Errorf("readme.txt mode = %v mtime = %v", fi.Mode(), fi.ModTime())
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "docs/latest")); string(b) != "hello" {
+		t.Errorf("symlink content = %q", b)
+	}
+}
+
+func TestExtractInsecure(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		symlink bool
+	}{
+		// 与 zip_test.go 中 TestReaderOpen 的条目名称相同
+		{"dot dot", []zipEntry{{name: "../test.txt", body: []byte("x")}}, false},
+		{"nested dot dot", []zipEntry{{name: "a/../../evil.txt", body: []byte("x")}}, false},
+		{"absolute", []zipEntry{{name: "/tmp/evil.txt", body: []byte("x")}}, false},
+		{"backslash", []zipEntry{{name: `..\evil.txt`, body: []byte("x")}}, false},
+		{"symlink escape", []zipEntry{{name: "link", body: []byte("../../etc"), mode: fs.ModeSymlink | 0o777}}, true},
+		{"absolute symlink", []zipEntry{{name: "link", body: []byte("/etc"), mode: fs.ModeSymlink | 0o777}}, true},
+		{"write through symlink", []zipEntry{
+			{name: "link", body: []byte("."), mode: fs.ModeSymlink | 0o777},
+			{name: "link/file", body: []byte("x")},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ziputil.Extract(buildZip(t, tt.entries...), filepath.Join(t.TempDir(), "out"), nil)
+			if !errors.Is(err, zip.ErrInsecurePath) {
+				t.Fatalf("err = %v, want zip.ErrInsecurePath", err)
+			}
+			var se *ziputil.SymlinkError
+			var pe *ziputil.InsecurePathError
+			if tt.symlink && !errors.As(err, &se) || !tt.symlink && !errors.As(err, &pe) {
+				t.Errorf("err = %T, symlink = %v", err, tt.symlink)
+			}
+		})
+	}
+}
+
+// TestExtractLinkChain y -> "." 和 x -> "y/.." 单看字符串都指向内部，
+// 但是在磁盘上 x 指向解压目录的上级目录，之后的目录条目 x/ 不能修改它的权限
+func TestExtractLinkChain(t *testing.T) {
+	entries := []zipEntry{
+		{name: "y", body: []byte("."), mode: fs.ModeSymlink | 0o777},
+		{name: "x", body: []byte("y/.."), mode: fs.ModeSymlink | 0o777},
+		{name: "x/", mode: fs.ModeDir | 0o777},
+	}
+	root := t.TempDir()
+	if err := os.Chmod(root, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	err := ziputil.Extract(buildZip(t, entries...), filepath.Join(root, "out"), nil)
+	var se *ziputil.SymlinkError
+	if !errors.As(err, &se) || se.Name != "x" {
+		t.Errorf("err = %v, want *SymlinkError for x", err)
+	}
+	if fi, _ := os.Stat(root); fi.Mode().Perm() != 0o700 {
+		t.Errorf("parent of the destination mode changed to %v", fi.Mode().Perm())
+	}
+
+	// 目标目录中已经存在指向外部的链接时，目录条目也不能跟随它
+	dir := filepath.Join(root, "existing")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..", filepath.Join(dir, "x")); err != nil {
+		t.Fatal(err)
+	}
+	err = ziputil.Extract(buildZip(t, entries[2]), dir, nil)
+	var pe *ziputil.InsecurePathError
+	if !errors.As(err, &pe) {
+		t.Errorf("existing symlink: err = %v, want *InsecurePathError", err)
+	}
+	if fi, _ := os.Stat(root); fi.Mode().Perm() != 0o700 {
+		t.Errorf("existing symlink: parent mode changed to %v", fi.Mode().Perm())
+	}
+}
+
+func TestExtractTestdata(t *testing.T) {
+	f, err := os.Open("../testdata/readme.zip")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	fi, _ := f.Stat()
+	r, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if err := ziputil.Extract(r, t.TempDir(), nil); err != nil {
+		t.Errorf("Extract(readme.zip): %v", err)
+	}
+
+}
+
+func TestExtractLimits(t *testing.T) {
+	bomb := zipEntry{name: "zeros.bin", body: make([]byte, 10<<20)}
+
+	var re *ziputil.RatioError
+	if err := ziputil.Extract(buildZip(t, bomb), t.TempDir(), nil); !errors.As(err, &re) {
+		t.Errorf("bomb err = %v, want *RatioError", err)
+	}
+
+	var se *ziputil.SizeError
+	opts := &ziputil.ExtractOptions{MaxTotalSize: 1 << 20}
+	if err := ziputil.Extract(buildZip(t, bomb), t.TempDir(), opts); !errors.As(err, &se) {
+		t.Errorf("size err = %v, want *SizeError", err)
+	}
+
+	var ce *ziputil.CountError
+	opts = &ziputil.ExtractOptions{MaxFiles: 1}
+	r := buildZip(t, zipEntry{name: "a", body: []byte("a")}, zipEntry{name: "b", body: []byte("b")})
+	if err := ziputil.Extract(r, t.TempDir(), opts); !errors.As(err, &ce) {
+		t.Errorf("count err = %v, want *CountError", err)
+	}
+
+	// 限制之内的压缩包可以正常解压
+	opts = &ziputil.ExtractOptions{MaxTotalSize: 10 << 20, MaxRatio: 2000}
+	if err := ziputil.Extract(buildZip(t, bomb), t.TempDir(), opts); err != nil {
+		t.Errorf("Extract within limits: %v", err)
+	}
+}
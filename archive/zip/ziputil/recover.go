@@ -0,0 +1,396 @@
+package ziputil
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	localHeaderLen       = 30
+	dataDescriptorLen    = 16 // 包括签名，ZIP64 时两个大小字段各多 4 个字节
+	zip64ExtraID         = 0x0001
+	flagEncrypted        = 0x1
+	flagDataDescriptor   = 0x8
+	flagUTF8             = 0x800
+	uint32Max            = 0xffffffff
+	signatureSearchChunk = 32 << 10
+)
+
+var (
+	localHeaderSig    = []byte("PK\x03\x04")
+	dataDescriptorSig = []byte("PK\x07\x08")
+)
+
+// localHeader 本地文件头中的字段
+//
+// 本地文件头没有外部属性，因此无法得到文件的权限；使用数据描述符时，CRC32 和大小都是 0。
+type localHeader struct {
+	flags            uint16
+	method           uint16
+	modified         time.Time
+	crc32            uint32
+	compressedSize   uint64
+	uncompressedSize uint64
+	name             string
+	extra            []byte
+	size             int64 // 包括文件名和扩展字段的头部长度
+}
+
+// hasDataDescriptor 报告大小和 CRC32 是否记录在数据之后的数据描述符中
+func (h *localHeader) hasDataDescriptor() bool {
+	return h.flags&flagDataDescriptor != 0 && h.compressedSize == 0
+}
+
+// fileHeader 转换为写入时使用的 zip.FileHeader
+func (h *localHeader) fileHeader() *zip.FileHeader {
+	return &zip.FileHeader{
+		Name:               h.name,
+		NonUTF8:            h.flags&flagUTF8 == 0 && !isASCII(h.name),
+		Method:             h.method,
+		Modified:           h.modified,
+		CRC32:              h.crc32,
+		CompressedSize64:   h.compressedSize,
+		UncompressedSize64: h.uncompressedSize,
+	}
+}
+
+// readLocalHeader 从 r 读取一个本地文件头，包括开头的签名
+func readLocalHeader(r io.Reader) (*localHeader, error) {
+	var buf [localHeaderLen]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buf[:4], localHeaderSig) {
+		return nil, fmt.Errorf("%w: bad local file header signature", zip.ErrFormat)
+	}
+	le := binary.LittleEndian
+	h := &localHeader{
+		flags:            le.Uint16(buf[6:]),
+		method:           le.Uint16(buf[8:]),
+		modified:         msDosTime(le.Uint16(buf[12:]), le.Uint16(buf[10:])),
+		crc32:            le.Uint32(buf[14:]),
+		compressedSize:   uint64(le.Uint32(buf[18:])),
+		uncompressedSize: uint64(le.Uint32(buf[22:])),
+	}
+	nameLen, extraLen := int(le.Uint16(buf[26:])), int(le.Uint16(buf[28:]))
+	rest := make([]byte, nameLen+extraLen)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	h.name, h.extra = string(rest[:nameLen]), rest[nameLen:]
+	h.size = int64(localHeaderLen + nameLen + extraLen)
+
+	// 本地文件头的 ZIP64 扩展字段中，未压缩和压缩后的大小总是同时出现
+	if h.compressedSize == uint32Max || h.uncompressedSize == uint32Max {
+		for extra := h.extra; len(extra) >= 4; {
+			id, size := le.Uint16(extra), int(le.Uint16(extra[2:]))
+			if len(extra) < 4+size {
+				break
+			}
+			if id == zip64ExtraID && size >= 16 {
+				h.uncompressedSize = le.Uint64(extra[4:])
+				h.compressedSize = le.Uint64(extra[12:])
+				break
+			}
+			extra = extra[4+size:]
+		}
+	}
+	return h, nil
+}
+
+// msDosTime 把 MS-DOS 格式的日期和时间转换为 time.Time，与 archive/zip 一样使用 UTC
+func msDosTime(date, t uint16) time.Time {
+	return time.Date(
+		int(date>>9+1980), time.Month(date>>5&0xf), int(date&0x1f),
+		int(t>>11), int(t>>5&0x3f), int(t&0x1f*2), 0, time.UTC,
+	)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// countReader 统计已经读取的字节数
+//
+// 实现了 io.ByteReader，flate 解压时逐字节读取，不会多读压缩流之后的数据，
+// 因此解压结束时 n 就是压缩数据的长度。
+type countReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// checksum 按照 method 解压 r，返回解压后数据的 CRC32 和大小
+func checksum(r io.Reader, method uint16) (uint32, uint64, error) {
+	switch method {
+	case zip.Store:
+	case zip.Deflate:
+		fr := flate.NewReader(r)
+		defer fr.Close()
+		r = fr
+	default:
+		return 0, 0, fmt.Errorf("%w: compression method %d", zip.ErrAlgorithm, method)
+	}
+	h := crc32.NewIEEE()
+	n, err := io.Copy(h, r)
+	return h.Sum32(), uint64(n), unexpectedEOF(err)
+}
+
+// SkippedEntry 恢复时放弃的条目
+type SkippedEntry struct {
+	Offset int64 // 本地文件头在原文件中的位置
+	Name   string
+	Err    error
+}
+
+// RecoverReport 恢复的结果
+type RecoverReport struct {
+	Recovered []string
+	Skipped   []SkippedEntry
+}
+
+// RecoverFile 从损坏的 zip 文件 src 中抢救条目，写入新的 zip 文件 dst
+func RecoverFile(dst, src string) (*RecoverReport, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return nil, err
+	}
+	report, err := Recover(out, in, fi.Size())
+	if err1 := out.Close(); err == nil {
+		err = err1
+	}
+	return report, err
+}
+
+// Recover 不依赖中央目录，扫描 r 中的本地文件头，把 CRC32 校验通过的条目写入 w 中新的 zip
+//
+// 适用于中央目录被截断或损坏、zip.NewReader 返回 zip.ErrFormat 的文件。
+// 压缩后的数据原样复制，不会重新压缩。限制如下：
+//   - 本地文件头中没有权限等外部属性，恢复出的条目都使用默认权限
+//   - 只能校验 Store 和 Deflate 压缩的条目，其他压缩方法和加密的条目都会被放弃
+//   - 使用数据描述符的 Store 条目需要向后搜索描述符，数据中恰好包含描述符签名时仍然可以正确识别
+func Recover(w io.Writer, r io.ReaderAt, size int64) (*RecoverReport, error) {
+	zw := zip.NewWriter(w)
+	report := &RecoverReport{}
+	for offset := int64(0); offset < size; {
+		start, err := findSignature(r, offset, size, localHeaderSig)
+		if err != nil {
+			return report, err
+		}
+		if start < 0 {
+			break
+		}
+		h, end, err := recoverEntry(zw, r, start, size)
+		if err != nil {
+			skipped := SkippedEntry{Offset: start, Err: err}
+			if h != nil {
+				skipped.Name = h.name
+			}
+			report.Skipped = append(report.Skipped, skipped)
+			// 签名可能只是数据中的巧合，从下一个字节继续搜索
+			offset = start + 1
+			continue
+		}
+		report.Recovered = append(report.Recovered, h.name)
+		offset = end
+	}
+	return report, zw.Close()
+}
+
+// recoverEntry 校验 offset 处的条目，通过后写入 zw，返回条目之后的位置
+func recoverEntry(zw *zip.Writer, r io.ReaderAt, offset, size int64) (*localHeader, int64, error) {
+	h, err := readLocalHeader(io.NewSectionReader(r, offset, size-offset))
+	if err != nil {
+		return nil, 0, unexpectedEOF(err)
+	}
+	if h.flags&flagEncrypted != 0 {
+		return h, 0, errors.New("ziputil: encrypted entry")
+	}
+	dataStart := offset + h.size
+	end := dataStart + int64(h.compressedSize)
+	if h.hasDataDescriptor() {
+		if end, err = findDataEnd(h, r, dataStart, size); err != nil {
+			return h, 0, err
+		}
+	} else {
+		if end > size {
+			return h, 0, io.ErrUnexpectedEOF
+		}
+		sum, n, err := checksum(io.NewSectionReader(r, dataStart, int64(h.compressedSize)), h.method)
+		if err != nil {
+			return h, 0, err
+		}
+		if sum != h.crc32 || n != h.uncompressedSize {
+			return h, 0, zip.ErrChecksum
+		}
+	}
+
+	fh := h.fileHeader()
+	fw, err := zw.CreateRaw(fh)
+	if err != nil {
+		return h, 0, err
+	}
+	if _, err := io.Copy(fw, io.NewSectionReader(r, dataStart, int64(fh.CompressedSize64))); err != nil {
+		return h, 0, err
+	}
+	return h, end, nil
+}
+
+// findDataEnd 确定使用数据描述符的条目的压缩数据长度，填入 h 的 CRC32 和大小，返回描述符之后的位置
+func findDataEnd(h *localHeader, r io.ReaderAt, dataStart, size int64) (int64, error) {
+	switch h.method {
+	case zip.Deflate:
+		// 解压到压缩流结束，读取的字节数就是压缩数据的长度
+		cr := &countReader{r: bufio.NewReader(io.NewSectionReader(r, dataStart, size-dataStart))}
+		sum, n, err := checksum(cr, h.method)
+		if err != nil {
+			return 0, err
+		}
+		h.crc32, h.uncompressedSize, h.compressedSize = sum, n, uint64(cr.n)
+		return readDataDescriptor(h, r, dataStart+cr.n)
+	case zip.Store:
+		return scanStored(h, r, dataStart, size)
+	}
+	return 0, fmt.Errorf("%w: compression method %d", zip.ErrAlgorithm, h.method)
+}
+
+// scanStored 确定使用数据描述符的 Store 条目的数据长度
+//
+// 未压缩的数据没有结束标记，只能从 dataStart 向后查找描述符的签名，直到 CRC32 和大小都吻合。
+// 查找只向前进行，CRC32 随着位置增量计算，每个字节只读取一次，而不是在每个候选位置从头重新计算。
+func scanStored(h *localHeader, r io.ReaderAt, dataStart, size int64) (int64, error) {
+	br := bufio.NewReaderSize(io.NewSectionReader(r, dataStart, size-dataStart), signatureSearchChunk)
+	crc := crc32.NewIEEE()
+	var n int64 // 已经计入 crc 的字节数，也就是下一个候选位置相对 dataStart 的偏移
+	for {
+		b, err := br.ReadSlice(dataDescriptorSig[0])
+		// ReadSlice 返回的数据在下一次读取时失效，先计入 crc，签名的第一个字节之后单独处理
+		found := err == nil
+		if found {
+			b = b[:len(b)-1]
+		}
+		crc.Write(b)
+		n += int64(len(b))
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF:
+			return 0, io.ErrUnexpectedEOF
+		case err != nil:
+			return 0, err
+		}
+
+		if rest, _ := br.Peek(len(dataDescriptorSig) - 1); bytes.Equal(rest, dataDescriptorSig[1:]) {
+			h.crc32, h.uncompressedSize, h.compressedSize = crc.Sum32(), uint64(n), uint64(n)
+			if end, err := readDataDescriptor(h, r, dataStart+n); err == nil {
+				return end, nil
+			}
+		}
+		crc.Write(dataDescriptorSig[:1])
+		n++
+	}
+}
+
+// readDataDescriptor 读取 offset 处的数据描述符并与 h 比较，返回描述符之后的位置
+func readDataDescriptor(h *localHeader, r io.ReaderAt, offset int64) (int64, error) {
+	buf := make([]byte, dataDescriptorLen+8)
+	n, _ := r.ReadAt(buf, offset)
+	if n := parseDataDescriptor(h, buf[:n]); n > 0 {
+		return offset + int64(n), nil
+	}
+	return 0, zip.ErrChecksum
+}
+
+// parseDataDescriptor 检查 b 开头的数据描述符是否与 h 的 CRC32 和大小一致，返回描述符的长度，不一致时返回 0
+//
+// 描述符的签名是可选的，大小字段可能是 4 个或 8 个字节，依次尝试所有组合。
+func parseDataDescriptor(h *localHeader, b []byte) int {
+	le := binary.LittleEndian
+	skip := 0
+	if bytes.HasPrefix(b, dataDescriptorSig) {
+		skip = 4
+	}
+	b = b[skip:]
+	for _, fieldLen := range []int{4, 8} {
+		if len(b) < 4+2*fieldLen || le.Uint32(b) != h.crc32 {
+			continue
+		}
+		var compressed, uncompressed uint64
+		if fieldLen == 8 {
+			compressed, uncompressed = le.Uint64(b[4:]), le.Uint64(b[12:])
+		} else {
+			compressed, uncompressed = uint64(le.Uint32(b[4:])), uint64(le.Uint32(b[8:]))
+		}
+		if compressed == h.compressedSize && uncompressed == h.uncompressedSize {
+			return skip + 4 + 2*fieldLen
+		}
+	}
+	return 0
+}
+
+// findSignature 返回 [offset, size) 中第一次出现 sig 的位置，没有找到时返回 -1
+func findSignature(r io.ReaderAt, offset, size int64, sig []byte) (int64, error) {
+	buf := make([]byte, signatureSearchChunk)
+	for offset < size {
+		chunk := buf
+		if remain := size - offset; remain < int64(len(chunk)) {
+			chunk = chunk[:remain]
+		}
+		n, err := r.ReadAt(chunk, offset)
+		if i := bytes.Index(chunk[:n], sig); i >= 0 {
+			return offset + int64(i), nil
+		}
+		if err != nil && err != io.EOF {
+			return -1, err
+		}
+		if n < len(sig) {
+			break
+		}
+		// 保留末尾不完整的签名，与下一块一起搜索
+		offset += int64(n - len(sig) + 1)
+	}
+	return -1, nil
+}
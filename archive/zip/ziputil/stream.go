@@ -0,0 +1,326 @@
+package ziputil
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// ErrStreamUnsupported 条目无法在只能向前读取的流中读取，只能通过中央目录读取
+//
+// 使用数据描述符的条目在本地文件头中没有压缩后的大小，只有 Store 和 Deflate 能够确定数据在哪里结束；
+// 其他压缩方法和加密的条目都会返回这个错误。
+var ErrStreamUnsupported = errors.New("ziputil: entry cannot be read from a stream")
+
+const streamBufferSize = 64 << 10
+
+var (
+	centralDirSig = []byte("PK\x01\x02")
+	endOfDirSig   = []byte("PK\x05\x06")
+	zip64EndSig   = []byte("PK\x06\x06")
+)
+
+// StreamReader 按顺序读取 zip 流中的本地文件头，不需要 io.ReaderAt 和文件大小，
+// 可以直接处理 HTTP 响应体或者管道中的数据，用法与 tar.Reader 相同
+//
+// 与通过中央目录读取相比有以下限制：
+//   - 外部属性只记录在中央目录中，返回的 FileHeader 没有权限和注释等信息
+//   - 中央目录中已经删除或被替换的条目，只要本地文件头还在流中，仍然会被读到，可能出现重名
+//   - 使用数据描述符的条目，FileHeader 的 CRC32 和大小在读到 io.EOF 之后才会被填入
+//   - 见 ErrStreamUnsupported
+//
+// 条目名称没有经过检查，解压到磁盘之前需要自己确认路径的安全性。
+type StreamReader struct {
+	r             *bufio.Reader
+	decompressors map[uint16]zip.Decompressor
+	cur           *streamFile
+	err           error
+}
+
+// NewStreamReader 返回从 r 读取 zip 数据的 StreamReader
+func NewStreamReader(r io.Reader) *StreamReader {
+	return &StreamReader{r: bufio.NewReaderSize(r, streamBufferSize)}
+}
+
+// RegisterDecompressor 为压缩方法 method 注册解压函数，只对本地文件头中记录了大小的条目有效
+func (s *StreamReader) RegisterDecompressor(method uint16, dcomp zip.Decompressor) {
+	if s.decompressors == nil {
+		s.decompressors = make(map[uint16]zip.Decompressor)
+	}
+	s.decompressors[method] = dcomp
+}
+
+// Next 前进到下一个条目，当前条目中未读取的数据会被丢弃，没有更多条目时返回 io.EOF
+func (s *StreamReader) Next() (*zip.FileHeader, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.cur != nil {
+		if err := s.cur.drain(); err != nil {
+			s.err = err
+			return nil, err
+		}
+		s.cur = nil
+	}
+
+	sig, err := s.r.Peek(4)
+	if err != nil {
+		s.err = unexpectedEOF(err)
+		return nil, s.err
+	}
+	switch {
+	case bytes.Equal(sig, centralDirSig), bytes.Equal(sig, endOfDirSig), bytes.Equal(sig, zip64EndSig):
+		// 到达中央目录，所有条目都已经读完
+		s.err = io.EOF
+		return nil, io.EOF
+	case !bytes.Equal(sig, localHeaderSig):
+		s.err = fmt.Errorf("%w: unexpected signature %q", zip.ErrFormat, sig)
+		return nil, s.err
+	}
+
+	h, err := readLocalHeader(s.r)
+	if err != nil {
+		s.err = unexpectedEOF(err)
+		return nil, s.err
+	}
+	fh := h.fileHeader()
+	fh.Flags = h.flags
+	s.cur = s.open(h, fh)
+	return fh, nil
+}
+
+// Read 读取当前条目解压后的内容，读到结尾时校验 CRC32 和大小，不一致时返回 zip.ErrChecksum
+func (s *StreamReader) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.cur == nil {
+		return 0, io.EOF
+	}
+	return s.cur.Read(p)
+}
+
+// open 根据压缩方法和是否使用数据描述符，返回读取条目内容的 streamFile
+func (s *StreamReader) open(h *localHeader, fh *zip.FileHeader) *streamFile {
+	f := &streamFile{h: h, fh: fh, hash: crc32.NewIEEE()}
+	if !h.hasDataDescriptor() {
+		f.raw = &limitReader{r: s.r, n: int64(h.compressedSize)}
+		switch dcomp := s.decompressor(h.method); {
+		case h.flags&flagEncrypted != 0:
+			f.err = fmt.Errorf("%w: %q is encrypted", ErrStreamUnsupported, h.name)
+		case h.method == zip.Store:
+			f.r = f.raw
+		case dcomp != nil:
+			rc := dcomp(f.raw)
+			f.r, f.closer = rc, rc
+		default:
+			f.err = fmt.Errorf("%w: %q uses compression method %d", zip.ErrAlgorithm, h.name, h.method)
+		}
+		return f
+	}
+
+	switch {
+	case h.flags&flagEncrypted != 0:
+		f.err = fmt.Errorf("%w: %q is encrypted", ErrStreamUnsupported, h.name)
+	case h.method == zip.Deflate:
+		// flate 通过 io.ByteReader 逐字节读取，解压结束时正好停在数据描述符之前
+		cr := &countReader{r: s.r}
+		fr := flate.NewReader(cr)
+		f.r, f.closer = fr, fr
+		f.done = func() error {
+			h.compressedSize = uint64(cr.n)
+			return s.readDataDescriptor(h)
+		}
+	case h.method == zip.Store:
+		f.r = &storedReader{r: s.r, h: h, crc: crc32.NewIEEE()}
+		f.done = func() error {
+			h.compressedSize = h.uncompressedSize
+			return s.readDataDescriptor(h)
+		}
+	default:
+		f.err = fmt.Errorf("%w: %q uses compression method %d with a data descriptor", ErrStreamUnsupported, h.name, h.method)
+	}
+	return f
+}
+
+func (s *StreamReader) decompressor(method uint16) zip.Decompressor {
+	if dcomp := s.decompressors[method]; dcomp != nil {
+		return dcomp
+	}
+	if method == zip.Deflate {
+		return flate.NewReader
+	}
+	return nil
+}
+
+// readDataDescriptor 读取并丢弃数据描述符，描述符必须与实际读到的 CRC32 和大小一致
+func (s *StreamReader) readDataDescriptor(h *localHeader) error {
+	b, _ := s.r.Peek(dataDescriptorLen + 8)
+	n := parseDataDescriptor(h, b)
+	if n == 0 {
+		return zip.ErrChecksum
+	}
+	_, err := s.r.Discard(n)
+	return err
+}
+
+// streamFile 读取一个条目的内容并计算 CRC32
+type streamFile struct {
+	h      *localHeader
+	fh     *zip.FileHeader
+	r      io.Reader
+	raw    io.Reader // 本地文件头中记录了大小时，未解压的数据
+	closer io.Closer
+	done   func() error // 使用数据描述符时，读完数据后读取描述符
+	hash   hash.Hash32
+	n      uint64
+	err    error
+}
+
+func (f *streamFile) Read(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	n, err := f.r.Read(p)
+	f.hash.Write(p[:n])
+	f.n += uint64(n)
+	if err == io.EOF {
+		err = f.finish()
+	}
+	if err != nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// finish 在读到结尾时校验 CRC32 和大小
+func (f *streamFile) finish() error {
+	if f.closer != nil {
+		if err := f.closer.Close(); err != nil {
+			return err
+		}
+	}
+	f.h.crc32, f.h.uncompressedSize = f.hash.Sum32(), f.n
+	if f.done != nil {
+		if err := f.done(); err != nil {
+			return err
+		}
+		f.fh.CRC32 = f.h.crc32
+		f.fh.CompressedSize64, f.fh.UncompressedSize64 = f.h.compressedSize, f.h.uncompressedSize
+		return io.EOF
+	}
+	if f.h.crc32 != f.fh.CRC32 || f.n != f.fh.UncompressedSize64 {
+		return zip.ErrChecksum
+	}
+	return io.EOF
+}
+
+// drain 读完当前条目剩下的数据
+//
+// 无法解压的条目只要本地文件头中记录了大小，就直接跳过压缩数据，不影响后面的条目。
+func (f *streamFile) drain() error {
+	if f.r != nil {
+		if _, err := io.Copy(io.Discard, f); err != nil {
+			return err
+		}
+	}
+	if f.raw != nil {
+		// 解压结束后，压缩数据可能还有剩余的填充
+		if _, err := io.Copy(io.Discard, f.raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limitReader 与 io.LimitReader 相同，但是数据不足 n 个字节时返回 io.ErrUnexpectedEOF
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if err == io.EOF && l.n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// storedReader 读取使用数据描述符的 Store 条目
+//
+// 未压缩的数据没有结束标记，只能在数据中寻找描述符签名，并且签名之后的 CRC32 和大小与已读取的数据吻合时，
+// 才认为数据在这里结束。
+type storedReader struct {
+	r    *bufio.Reader
+	h    *localHeader
+	crc  hash.Hash32
+	n    uint64
+	done bool
+}
+
+func (s *storedReader) Read(p []byte) (int, error) {
+	if s.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	peek, err := s.r.Peek(s.r.Size())
+	if len(peek) == 0 {
+		return 0, unexpectedEOF(err)
+	}
+	end := len(peek)
+	if err == nil {
+		// 保留可能跨越缓冲区的描述符，留到下一次读取时判断
+		end -= dataDescriptorLen + 8
+	}
+	for i := 0; i < len(peek); {
+		j := bytes.Index(peek[i:], dataDescriptorSig)
+		if j < 0 {
+			break
+		}
+		i += j
+		if i > end {
+			break
+		}
+		if s.matches(peek[:i], peek[i:]) {
+			if i == 0 {
+				s.done = true
+				return 0, io.EOF
+			}
+			end = i
+			break
+		}
+		i++
+	}
+	if end > len(p) {
+		end = len(p)
+	}
+	n, _ := s.r.Read(p[:end])
+	s.crc.Write(p[:n])
+	s.n += uint64(n)
+	return n, nil
+}
+
+// matches 报告 b 是否为 data 之后的数据描述符
+func (s *storedReader) matches(data, b []byte) bool {
+	h := *s.h
+	h.crc32 = crc32.Update(s.crc.Sum32(), crc32.IEEETable, data)
+	h.compressedSize = s.n + uint64(len(data))
+	h.uncompressedSize = h.compressedSize
+	return parseDataDescriptor(&h, b) > 0
+}
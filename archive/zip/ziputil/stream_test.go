@@ -0,0 +1,177 @@
+package ziputil_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"standard-library-examples/archive/zip/ziputil"
+)
+
+func TestStreamReader(t *testing.T) {
+	// 未压缩的数据中包含数据描述符签名，并且超过读取缓冲区的大小
+	stored := []byte(strings.Repeat("PK\x07\x08 not a descriptor ", 10000))
+	known := []byte("sizes in the local header")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range []struct {
+		name   string
+		method uint16
+		body   []byte
+	}{
+		{"deflate.txt", zip.Deflate, []byte(strings.Repeat("deflate ", 1000))},
+		{"stored.bin", zip.Store, stored},
+		{"empty/", zip.Store, nil},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: e.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(e.body)
+	}
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "known.txt",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(known),
+		CompressedSize64:   uint64(len(known)),
+		UncompressedSize64: uint64(len(known)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(known)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 逐字节读取，模拟网络连接中零散到达的数据
+	sr := ziputil.NewStreamReader(iotest.OneByteReader(bytes.NewReader(buf.Bytes())))
+	want := openZip(t, buf.Bytes())
+	for i := 0; ; i++ {
+		fh, err := sr.Next()
+		if err == io.EOF {
+			if i != len(want.File) {
+				t.Errorf("read %d entries, want %d", i, len(want.File))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		f := want.File[i]
+		got, err := io.ReadAll(sr)
+		if err != nil {
+			t.Fatalf("%s: ReadAll: %v", fh.Name, err)
+		}
+		rc, _ := f.Open()
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		if fh.Name != f.Name || !bytes.Equal(got, body) {
+			t.Errorf("entry %d = %s (%d bytes), want %s (%d bytes)", i, fh.Name, len(got), f.Name, len(body))
+		}
+		// 读完之后，数据描述符中的 CRC32 和大小已经填入
+		if fh.CRC32 != f.CRC32 || fh.CompressedSize64 != f.CompressedSize64 || fh.UncompressedSize64 != f.UncompressedSize64 {
+			t.Errorf("%s: crc %#x size %d/%d, want crc %#x size %d/%d", fh.Name,
+				fh.CRC32, fh.CompressedSize64, fh.UncompressedSize64, f.CRC32, f.CompressedSize64, f.UncompressedSize64)
+		}
+	}
+}
+
+// TestStreamReaderSkip 不读取内容时，Next 直接跳到下一个条目
+func TestStreamReaderSkip(t *testing.T) {
+	sr := ziputil.NewStreamReader(bytes.NewReader(zipBytes(t, "a.txt", "b.txt", "c.txt")))
+	var names []string
+	for {
+		fh, err := sr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		names = append(names, fh.Name)
+	}
+	if got := strings.Join(names, ","); got != "a.txt,b.txt,c.txt" {
+		t.Errorf("names = %s", got)
+	}
+}
+
+func TestStreamReaderErrors(t *testing.T) {
+	data := zipBytes(t, "a.txt", "b.txt")
+
+	// 修改未压缩条目 b.txt 的数据，描述符与数据不再吻合，读到流的结尾也找不到描述符
+	damaged := bytes.Clone(data)
+	damaged[bytes.Index(damaged, []byte("b.txt\nb.txt"))] = 'B'
+	sr := ziputil.NewStreamReader(bytes.NewReader(damaged))
+	if _, err := sr.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if _, err := sr.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if _, err := io.ReadAll(sr); err == nil {
+		t.Errorf("reading damaged entry should fail")
+	}
+
+	// 截断的流
+	sr = ziputil.NewStreamReader(bytes.NewReader(data[:len(data)/3]))
+	var err error
+	for err == nil {
+		if _, err = sr.Next(); err == nil {
+			_, err = io.ReadAll(sr)
+		}
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated stream err = %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	// 使用数据描述符的未知压缩方法无法确定数据的长度
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	zw.RegisterCompressor(99, func(w io.Writer) (io.WriteCloser, error) { return nopWriteCloser{w}, nil })
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "custom", Method: 99})
+	_, _ = w.Write([]byte("data"))
+	_ = zw.Close()
+	sr = ziputil.NewStreamReader(&buf)
+	if _, err := sr.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if _, err := io.ReadAll(sr); !errors.Is(err, ziputil.ErrStreamUnsupported) {
+		t.Errorf("custom method err = %v, want ErrStreamUnsupported", err)
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func ExampleStreamReader() {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("hello.txt")
+	_, _ = w.Write([]byte("hello, stream"))
+	_ = zw.Close()
+
+	// 只需要 io.Reader，例如 http.Response.Body
+	sr := ziputil.NewStreamReader(io.MultiReader(&buf))
+	for {
+		fh, err := sr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
+		body, _ := io.ReadAll(sr)
+		fmt.Printf("%s: %s\n", fh.Name, body)
+	}
+	// Output:
+	// hello.txt: hello, stream
+}
@@ -0,0 +1,210 @@
+package ziputil
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Update 对已有 zip 的一组修改：添加、替换和删除条目
+//
+// 未修改的条目通过 zip.Writer.Copy 原样复制压缩后的数据，不需要解压和重新压缩，
+// 因此更新大的压缩包时，耗时主要取决于磁盘复制的速度。
+type Update struct {
+	puts    map[string]put
+	order   []string
+	deletes map[string]bool
+	comment *string
+}
+
+// put 添加或替换的条目
+type put struct {
+	fh   *zip.FileHeader
+	open func() (io.ReadCloser, error)
+}
+
+// NewUpdate 返回一个空的 Update
+func NewUpdate() *Update {
+	return &Update{puts: make(map[string]put), deletes: make(map[string]bool)}
+}
+
+// Put 添加名称为 fh.Name 的条目，已经存在时替换它并保持原来的位置
+//
+// open 在写入时才会被调用，返回的数据按照 fh.Method 压缩
+func (u *Update) Put(fh *zip.FileHeader, open func() (io.ReadCloser, error)) {
+	if _, ok := u.puts[fh.Name]; !ok {
+		u.order = append(u.order, fh.Name)
+	}
+	delete(u.deletes, fh.Name)
+	u.puts[fh.Name] = put{fh: fh, open: open}
+}
+
+// PutBytes 使用 Deflate 添加或替换内容为 data 的文件
+func (u *Update) PutBytes(name string, data []byte, mode fs.FileMode) {
+	fh := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	fh.SetMode(mode)
+	u.Put(fh, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// PutFile 添加或替换条目 name，内容来自磁盘上的文件 path，保留其权限和修改时间
+func (u *Update) PutFile(name, path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fh, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	fh.Name = name
+	fh.Method = zip.Deflate
+	u.Put(fh, func() (io.ReadCloser, error) { return os.Open(path) })
+	return nil
+}
+
+// Delete 删除条目 name，名称以 "/" 结尾时删除整个目录
+func (u *Update) Delete(name string) {
+	delete(u.puts, name)
+	u.deletes[name] = true
+}
+
+// SetComment 修改压缩包的注释
+func (u *Update) SetComment(comment string) {
+	u.comment = &comment
+}
+
+// deleted 判断 name 是否被删除
+func (u *Update) deleted(name string) bool {
+	if u.deletes[name] {
+		return true
+	}
+	for d := range u.deletes {
+		if strings.HasSuffix(d, "/") && strings.HasPrefix(name, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Write 把 r 应用修改后的结果写入 w，重新生成中央目录
+//
+// 删除不存在的条目会返回包装了 fs.ErrNotExist 的错误。
+// r 中有多个同名的条目并且该名称被 Put 替换时，只在第一个条目的位置写入一次新内容，其余同名条目被丢弃。
+func (u *Update) Write(w io.Writer, r *zip.Reader) error {
+	existing := make(map[string]bool, len(r.File))
+	for _, f := range r.File {
+		existing[f.Name] = true
+	}
+	var missing []string
+	for name := range u.deletes {
+		if !existing[name] && !strings.HasSuffix(name, "/") {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("ziputil: delete %s: %w", strings.Join(missing, ", "), fs.ErrNotExist)
+	}
+
+	zw := zip.NewWriter(w)
+	comment := r.Comment
+	if u.comment != nil {
+		comment = *u.comment
+	}
+	if err := zw.SetComment(comment); err != nil {
+		return err
+	}
+
+	written := make(map[string]bool)
+	for _, f := range r.File {
+		if p, ok := u.puts[f.Name]; ok {
+			if written[f.Name] {
+				continue
+			}
+			if err := writePut(zw, p); err != nil {
+				return err
+			}
+			written[f.Name] = true
+			continue
+		}
+		if u.deleted(f.Name) {
+			continue
+		}
+		if err := zw.Copy(f); err != nil {
+			return fmt.Errorf("ziputil: copy %s: %w", f.Name, err)
+		}
+	}
+	for _, name := range u.order {
+		if p, ok := u.puts[name]; ok && !written[name] {
+			if err := writePut(zw, p); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+func writePut(zw *zip.Writer, p put) error {
+	fw, err := zw.CreateHeader(p.fh)
+	if err != nil {
+		return err
+	}
+	rc, err := p.open()
+	if err != nil {
+		return fmt.Errorf("ziputil: open %s: %w", p.fh.Name, err)
+	}
+	defer rc.Close()
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+// Apply 把修改应用到磁盘上的 zip 文件 path
+//
+// 结果先写入同一目录下的临时文件，同步到磁盘后再通过 os.Rename 原子地替换原文件，
+// 任何步骤失败时原文件保持不变。
+func (u *Update) Apply(path string) (err error) {
+	rc, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = u.Write(tmp, &rc.Reader); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(fi.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	// Windows 上不能替换仍然打开着的文件
+	_ = rc.Close()
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,155 @@
+package ziputil_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"standard-library-examples/archive/zip/ziputil"
+)
+
+// writeZipFile 把条目写入磁盘上的 zip 文件
+func writeZipFile(t *testing.T, path string, entries ...zipEntry) {
+	t.Helper()
+	r := buildZip(t, entries...)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		if err := zw.Copy(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rawData 返回条目压缩后的原始数据
+func rawData(t *testing.T, f *zip.File) []byte {
+	t.Helper()
+	r, err := f.OpenRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(r)
+	return b
+}
+
+func TestUpdateApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	big := []byte(strings.Repeat("artifact ", 10000))
+	writeZipFile(t, path,
+		zipEntry{name: "big.bin", body: big},
+		zipEntry{name: "config.json", body: []byte(`{"v":1}`)},
+		zipEntry{name: "logs/a.log", body: []byte("a")},
+		zipEntry{name: "logs/b.log", body: []byte("b")},
+	)
+	before, _ := zip.OpenReader(path)
+	bigRaw := rawData(t, before.File[0])
+	_ = before.Close()
+
+	u := ziputil.NewUpdate()
+	u.PutBytes("config.json", []byte(`{"v":2}`), 0o644)
+	u.PutBytes("VERSION", []byte("2.0"), 0o644)
+	u.Delete("logs/")
+	u.SetComment("updated")
+	if err := u.Apply(path); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	rc, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("OpenReader: %v", err)
+	}
+	defer rc.Close()
+
+	var names []string
+	for _, f := range rc.File {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "big.bin,config.json,VERSION" {
+		t.Errorf("entries = %s", got)
+	}
+	if rc.Comment != "updated" {
+		t.Errorf("Comment = %q", rc.Comment)
+	}
+	// 未修改的条目原样复制，没有重新压缩
+	if !bytes.Equal(rawData(t, rc.File[0]), bigRaw) {
+		t.Errorf("big.bin was recompressed")
+	}
+	if b, _ := fs.ReadFile(rc, "config.json"); string(b) != `{"v":2}` {
+		t.Errorf("config.json = %s", b)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, want 0640", fi.Mode())
+	}
+}
+
+// TestUpdateAtomic 更新失败时原文件保持不变
+// TestUpdateDuplicate 原压缩包中有同名的条目时，Put 的内容只写入一次
+func TestUpdateDuplicate(t *testing.T) {
+	r := buildZip(t,
+		zipEntry{name: "a.txt", body: []byte("old 1")},
+		zipEntry{name: "b.txt", body: []byte("b")},
+		zipEntry{name: "a.txt", body: []byte("old 2")},
+	)
+	opens := 0
+	u := ziputil.NewUpdate()
+	u.Put(&zip.FileHeader{Name: "a.txt", Method: zip.Deflate}, func() (io.ReadCloser, error) {
+		opens++
+		return io.NopCloser(strings.NewReader("new")), nil
+	})
+
+	var buf bytes.Buffer
+	if err := u.Write(&buf, r); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if opens != 1 {
+		t.Errorf("open called %d times, want 1", opens)
+	}
+	out, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range out.File {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "a.txt,b.txt" {
+		t.Errorf("entries = %v", names)
+	}
+}
+
+func TestUpdateAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.zip")
+	writeZipFile(t, path, zipEntry{name: "a.txt", body: []byte("a")})
+	original, _ := os.ReadFile(path)
+
+	u := ziputil.NewUpdate()
+	u.Put(&zip.FileHeader{Name: "b.txt"}, func() (io.ReadCloser, error) {
+		return nil, errors.New("source unavailable")
+	})
+	if err := u.Apply(path); err == nil {
+		t.Fatalf("Apply should fail")
+	}
+	if b, _ := os.ReadFile(path); !bytes.Equal(b, original) {
+		t.Errorf("original file was modified")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("temporary file left behind: %v", files)
+	}
+
+	u = ziputil.NewUpdate()
+	u.Delete("missing.txt")
+	if err := u.Apply(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Delete(missing) err = %v, want fs.ErrNotExist", err)
+	}
+}
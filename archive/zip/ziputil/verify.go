@@ -0,0 +1,155 @@
+package ziputil
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// ProblemKind 校验发现的问题类型
+type ProblemKind int
+
+const (
+	ProblemChecksum  ProblemKind = iota + 1 // 解压后的 CRC32 与中央目录中的不一致
+	ProblemSize                             // 解压后的大小与中央目录中的不一致
+	ProblemDuplicate                        // 多个条目使用同一个名称
+	ProblemOverlap                          // 条目的数据与其他条目重叠
+	ProblemRead                             // 条目无法打开或解压
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case ProblemChecksum:
+		return "checksum"
+	case ProblemSize:
+		return "size"
+	case ProblemDuplicate:
+		return "duplicate"
+	case ProblemOverlap:
+		return "overlap"
+	case ProblemRead:
+		return "read"
+	}
+	return fmt.Sprintf("ProblemKind(%d)", int(k))
+}
+
+// Problem 一个条目上发现的问题
+type Problem struct {
+	Name   string
+	Kind   ProblemKind
+	Detail string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Name, p.Kind, p.Detail)
+}
+
+// Report 校验的结果
+type Report struct {
+	Files    int
+	Problems []Problem
+}
+
+// OK 没有发现任何问题时返回 true
+func (r *Report) OK() bool { return len(r.Problems) == 0 }
+
+// VerifyFile 打开并校验 zip 文件 name
+//
+// 中央目录损坏时返回的错误可以用 errors.Is 与 zip.ErrFormat 比较，这时可以用 RecoverFile 抢救其中的条目。
+func VerifyFile(name string) (*Report, error) {
+	rc, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return Verify(&rc.Reader), nil
+}
+
+// Verify 解压 r 中的每个条目，检查 CRC32 和大小是否与中央目录一致，同时检查重复的名称和相互重叠的条目
+//
+// 条目无法读取时记录为 ProblemRead，不会中断对其余条目的校验。
+// Store 和 Deflate 之外的压缩方法通过 zip.File.Open 解压，只能报告读取失败，无法区分具体原因。
+func Verify(r *zip.Reader) *Report {
+	report := &Report{Files: len(r.File)}
+	seen := make(map[string]bool, len(r.File))
+	type span struct {
+		name       string
+		start, end int64
+	}
+	spans := make([]span, 0, len(r.File))
+	for _, f := range r.File {
+		if seen[f.Name] {
+			report.add(f.Name, ProblemDuplicate, "name appears more than once")
+		}
+		seen[f.Name] = true
+
+		offset, err := f.DataOffset()
+		if err != nil {
+			report.add(f.Name, ProblemRead, err.Error())
+			continue
+		}
+		spans = append(spans, span{f.Name, offset, offset + int64(f.CompressedSize64)})
+		verifyFile(report, f)
+	}
+
+	// 按数据的起始位置排序后，只需要和前面结束得最晚的条目比较
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var last *span
+	for i := range spans {
+		s := &spans[i]
+		if last != nil && s.start < last.end {
+			report.add(s.name, ProblemOverlap, fmt.Sprintf("data at offset %d overlaps %q", s.start, last.name))
+		}
+		if last == nil || s.end > last.end {
+			last = s
+		}
+	}
+	return report
+}
+
+func (r *Report) add(name string, kind ProblemKind, detail string) {
+	r.Problems = append(r.Problems, Problem{Name: name, Kind: kind, Detail: detail})
+}
+
+// verifyFile 解压 f 并比较 CRC32 和大小
+func verifyFile(report *Report, f *zip.File) {
+	var rd io.Reader
+	switch f.Method {
+	case zip.Store, zip.Deflate:
+		raw, err := f.OpenRaw()
+		if err != nil {
+			report.add(f.Name, ProblemRead, err.Error())
+			return
+		}
+		rd = raw
+		if f.Method == zip.Deflate {
+			fr := flate.NewReader(raw)
+			defer fr.Close()
+			rd = fr
+		}
+	default:
+		rc, err := f.Open()
+		if err != nil {
+			report.add(f.Name, ProblemRead, err.Error())
+			return
+		}
+		defer rc.Close()
+		rd = rc
+	}
+
+	h := crc32.NewIEEE()
+	n, err := io.Copy(h, rd)
+	if err != nil {
+		report.add(f.Name, ProblemRead, err.Error())
+		return
+	}
+	if uint64(n) != f.UncompressedSize64 {
+		report.add(f.Name, ProblemSize, fmt.Sprintf("got %d bytes, want %d", n, f.UncompressedSize64))
+	}
+	if sum := h.Sum32(); sum != f.CRC32 {
+		report.add(f.Name, ProblemChecksum, fmt.Sprintf("got %#08x, want %#08x", sum, f.CRC32))
+	}
+}
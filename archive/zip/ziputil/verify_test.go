@@ -0,0 +1,157 @@
+package ziputil_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"standard-library-examples/archive/zip/ziputil"
+)
+
+// zipBytes 构造 zip 数据，Store 和 Deflate 条目交替出现，两者都使用数据描述符
+func zipBytes(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, name := range names {
+		method := zip.Deflate
+		if i%2 == 1 {
+			method = zip.Store
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatalf("CreateHeader(%s): %v", name, err)
+		}
+		if _, err := w.Write([]byte(strings.Repeat(name+"\n", 100))); err != nil {
+			t.Fatalf("Write(%s): %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// problems 把 Report 中的问题按照 "名称:类型" 的形式列出
+func problems(r *ziputil.Report) string {
+	var list []string
+	for _, p := range r.Problems {
+		list = append(list, p.Name+":"+p.Kind.String())
+	}
+	return strings.Join(list, ",")
+}
+
+func TestVerify(t *testing.T) {
+	data := zipBytes(t, "a.txt", "b.txt", "c.txt")
+	if report := ziputil.Verify(openZip(t, data)); !report.OK() || report.Files != 3 {
+		t.Fatalf("intact archive: files = %d, problems = %v", report.Files, report.Problems)
+	}
+
+	// 修改未压缩条目 b.txt 数据中的一个字节
+	damaged := bytes.Clone(data)
+	i := bytes.Index(damaged, []byte("b.txt\nb.txt"))
+	damaged[i] = 'B'
+	if got := problems(ziputil.Verify(openZip(t, damaged))); got != "b.txt:checksum" {
+		t.Errorf("damaged data: problems = %s", got)
+	}
+
+	// 中央目录中第二个条目的本地文件头偏移量改为 0，与第一个条目重叠
+	overlapped := bytes.Clone(data)
+	cd := bytes.Index(overlapped, []byte("PK\x01\x02"))
+	cd += bytes.Index(overlapped[cd+4:], []byte("PK\x01\x02")) + 4
+	binary.LittleEndian.PutUint32(overlapped[cd+42:], 0)
+	report := ziputil.Verify(openZip(t, overlapped))
+	if got := problems(report); !strings.Contains(got, "b.txt:overlap") {
+		t.Errorf("overlapping entries: problems = %s", got)
+	}
+
+	dup := zipBytes(t, "a.txt", "a.txt")
+	if got := problems(ziputil.Verify(openZip(t, dup))); got != "a.txt:duplicate" {
+		t.Errorf("duplicate names: problems = %s", got)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	names := []string{"a.txt", "b.txt", "c.txt", "d.txt"}
+	data := zipBytes(t, names...)
+
+	// 截断中央目录，并且损坏 c.txt 的数据
+	damaged := data[:bytes.Index(data, []byte("PK\x01\x02"))+10]
+	damaged = bytes.Clone(damaged)
+	c := bytes.Index(damaged, []byte("c.txt")) + len("c.txt")
+	damaged[c+2] ^= 0xff
+
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "damaged.zip"), filepath.Join(dir, "recovered.zip")
+	if err := os.WriteFile(src, damaged, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ziputil.VerifyFile(src); !errors.Is(err, zip.ErrFormat) {
+		t.Fatalf("VerifyFile(damaged) err = %v, want zip.ErrFormat", err)
+	}
+
+	report, err := ziputil.RecoverFile(dst, src)
+	if err != nil {
+		t.Fatalf("RecoverFile: %v", err)
+	}
+	if got := strings.Join(report.Recovered, ","); got != "a.txt,b.txt,d.txt" {
+		t.Errorf("Recovered = %s", got)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Name != "c.txt" {
+		t.Errorf("Skipped = %+v", report.Skipped)
+	}
+
+	verified, err := ziputil.VerifyFile(dst)
+	if err != nil || !verified.OK() {
+		t.Fatalf("VerifyFile(recovered) = %+v, %v", verified, err)
+	}
+	rc, _ := zip.OpenReader(dst)
+	defer rc.Close()
+	for _, name := range []string{"a.txt", "b.txt", "d.txt"} {
+		b, err := fs.ReadFile(rc, name)
+		if err != nil || string(b) != strings.Repeat(name+"\n", 100) {
+			t.Errorf("%s = %.20q, %v", name, b, err)
+		}
+	}
+}
+
+// TestRecoverStoredSignature 未压缩的数据中出现描述符签名时，跳过不吻合的候选位置继续向后查找
+func TestRecoverStoredSignature(t *testing.T) {
+	body := []byte(strings.Repeat("data PK\x07\x08 fake descriptor\n", 200))
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "sig.bin", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(body)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 去掉中央目录，只能通过扫描本地文件头恢复
+	data := buf.Bytes()
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "damaged.zip"), filepath.Join(dir, "recovered.zip")
+	if err := os.WriteFile(src, data[:bytes.Index(data, []byte("PK\x01\x02"))], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ziputil.RecoverFile(dst, src)
+	if err != nil || len(report.Recovered) != 1 {
+		t.Fatalf("RecoverFile = %+v, %v", report, err)
+	}
+	rc, err := zip.OpenReader(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if b, err := fs.ReadFile(rc, "sig.bin"); err != nil || !bytes.Equal(b, body) {
+		t.Errorf("sig.bin = %.20q (%d bytes), %v", b, len(b), err)
+	}
+}
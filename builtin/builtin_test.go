@@ -1,7 +1,10 @@
 package builtin_test
 
 import (
+	"errors"
 	"testing"
+
+	"standard-library-examples/builtin/try"
 )
 
 func TestAppend(t *testing.T) {
@@ -138,21 +141,16 @@ func TestPanic(t *testing.T) {
 	}()
 }
 
-// 使用 recover 捕获异常
+// 使用 recover 捕获异常，try.Do 在 defer 中调用 recover，并保留 panic 的值和调用栈
 func TestRecover(t *testing.T) {
 	t.Log("Hello")
-	try(func() {
+	_, err := try.Do(func() (string, error) {
 		panic("World")
-	}, func(e interface{}) {
-		t.Logf("catch: %v\n", e)
 	})
-}
 
-func try(fun func(), catch func(interface{})) {
-	defer func() {
-		if err := recover(); err != nil {
-			catch(err)
-		}
-	}()
-	fun()
+	var pe *try.PanicError
+	if errors.As(err, &pe) {
+		t.Logf("catch: %v\n", pe.Value)
+		t.Logf("stack: %s\n", pe.Stack)
+	}
 }
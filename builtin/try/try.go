@@ -0,0 +1,107 @@
+// Package try 把 panic 转换为 error
+//
+// 与直接使用 recover 相比，转换后的 PanicError 保留了 panic 的原始值和发生 panic 时的调用栈，
+// 如果原始值是 error，还可以通过 errors.Is 和 errors.As 检查。
+package try
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError 由 panic 转换而来的错误
+type PanicError struct {
+	Value any    // 传给 panic 的值
+	Stack []byte // 发生 panic 的 goroutine 的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap panic 的值是 error 时返回该 error，使 errors.Is 和 errors.As 可以穿透 PanicError
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// ErrGoexit 由 Group.Go 执行的函数调用了 runtime.Goexit，例如在测试中调用 t.FailNow
+var ErrGoexit = errors.New("try: runtime.Goexit called")
+
+// Do 执行 fn 并返回其结果，fn 发生 panic 时返回 *PanicError
+//
+// runtime.Goexit 不是 panic，也无法被 recover 停止：fn 调用了 runtime.Goexit 时
+// Do 不会返回，当前 goroutine 照常退出，而不会被误报为 Value 为 nil 的 PanicError。
+func Do[T any](fn func() (T, error)) (result T, err error) {
+	completed := false
+	var pe *PanicError
+	func() {
+		defer func() {
+			// 使用 completed 而不是 recover() != nil 判断，panic(nil) 同样能被捕获
+			if !completed {
+				pe = &PanicError{Value: recover(), Stack: debug.Stack()}
+			}
+		}()
+		result, err = fn()
+		completed = true
+	}()
+	// recover 只能停止 panic，Goexit 时不会执行到这里
+	if pe != nil {
+		return result, pe
+	}
+	return result, err
+}
+
+// Run 执行没有返回值的 fn，fn 发生 panic 时返回 *PanicError
+func Run(fn func()) error {
+	_, err := Do(func() (struct{}, error) {
+		fn()
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// Group 在多个 goroutine 中执行函数，捕获其中的 panic，避免后台任务的 panic 导致进程退出
+//
+// 零值可以直接使用
+type Group struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// Go 在新的 goroutine 中执行 fn，fn 返回的错误和 panic 都会在 Wait 时返回，
+// fn 调用了 runtime.Goexit 时返回 ErrGoexit
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		returned := false
+		defer func() {
+			if !returned {
+				g.add(ErrGoexit)
+			}
+		}()
+		_, err := Do(func() (struct{}, error) { return struct{}{}, fn() })
+		returned = true
+		if err != nil {
+			g.add(err)
+		}
+	}()
+}
+
+func (g *Group) add(err error) {
+	g.mu.Lock()
+	g.errs = append(g.errs, err)
+	g.mu.Unlock()
+}
+
+// Wait 等待所有 goroutine 结束，使用 errors.Join 合并它们返回的错误
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
@@ -0,0 +1,113 @@
+package try_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"standard-library-examples/builtin/try"
+)
+
+func TestDo(t *testing.T) {
+	n, err := try.Do(func() (int, error) { return 42, nil })
+	if n != 42 || err != nil {
+		t.Errorf("Do = %d, %v, want 42, nil", n, err)
+	}
+
+	_, err = try.Do(func() (int, error) { return 0, io.EOF })
+	if err != io.EOF {
+		t.Errorf("Do err = %v, want io.EOF", err)
+	}
+}
+
+func TestDoPanic(t *testing.T) {
+	_, err := try.Do(func() (string, error) {
+		panic("World")
+	})
+
+	var pe *try.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want *PanicError", err)
+	}
+	if pe.Value != "World" {
+		t.Errorf("Value = %v, want World", pe.Value)
+	}
+	// 调用栈中包含发生 panic 的函数
+	if !strings.Contains(string(pe.Stack), "TestDoPanic") {
+		t.Errorf("stack does not contain the panicking function:\n%s", pe.Stack)
+	}
+}
+
+func TestPanicErrorUnwrap(t *testing.T) {
+	err := try.Run(func() {
+		_, err := os.Open("testdata/not-exist")
+		panic(err)
+	})
+
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("errors.Is(%v, os.ErrNotExist) = false", err)
+	}
+	var pathErr *os.PathError
+	if !errors.As(err, &pathErr) {
+		t.Errorf("errors.As(%v, *os.PathError) = false", err)
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	err := try.Run(func() {
+		var m map[string]int
+		m["a"] = 1
+	})
+	if err == nil || !strings.Contains(err.Error(), "nil map") {
+		t.Errorf("err = %v, want assignment to nil map", err)
+	}
+}
+
+// TestGoexit runtime.Goexit 不会被当作 panic，Do 不返回，goroutine 照常退出
+func TestGoexit(t *testing.T) {
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		err := try.Run(runtime.Goexit)
+		done <- err
+	}()
+	if err, ok := <-done; ok {
+		t.Errorf("Run returned %v after runtime.Goexit", err)
+	}
+
+	var g try.Group
+	g.Go(func() error {
+		runtime.Goexit()
+		return nil
+	})
+	if err := g.Wait(); !errors.Is(err, try.ErrGoexit) {
+		t.Errorf("Wait = %v, want ErrGoexit", err)
+	}
+	var pe *try.PanicError
+	if errors.As(g.Wait(), &pe) {
+		t.Errorf("Goexit reported as PanicError: %v", pe)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var g try.Group
+	g.Go(func() error { return nil })
+	g.Go(func() error { return io.ErrUnexpectedEOF })
+	g.Go(func() error {
+		var s []int
+		_ = s[1]
+		return nil
+	})
+
+	err := g.Wait()
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(%v, io.ErrUnexpectedEOF) = false", err)
+	}
+	var pe *try.PanicError
+	if !errors.As(err, &pe) {
+		t.Errorf("errors.As(%v, *PanicError) = false", err)
+	}
+}
@@ -0,0 +1,237 @@
+// Package bench 比较 compress 下各种编解码器在不同压缩级别下的压缩率、速度和内存分配
+//
+// 编解码器通过 compress/codec 按名称创建，因此注册到 codec.Default 的自定义格式也可以参与比较。
+package bench
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"standard-library-examples/compress/codec"
+)
+
+// Case 一种编解码器和压缩级别的组合，没有压缩级别的格式 Level 为 0
+type Case struct {
+	Codec string `json:"codec"`
+	Level int    `json:"level"`
+}
+
+// DefaultCases flate、gzip、zlib 从 BestSpeed 到 BestCompression 的每个级别，以及 LSB 和 MSB 两种位序的 lzw
+func DefaultCases() []Case {
+	var cases []Case
+	for _, name := range []string{"flate", "gzip", "zlib"} {
+		for level := flate.BestSpeed; level <= flate.BestCompression; level++ {
+			cases = append(cases, Case{Codec: name, Level: level})
+		}
+	}
+	return append(cases, Case{Codec: "lzw"}, Case{Codec: "lzw-msb"})
+}
+
+// Input 一个测试输入
+type Input struct {
+	Name string
+	Data []byte
+}
+
+// Load 读取 paths 中的文件作为测试输入，目录会被递归遍历
+//
+// 可以被 codec 识别的压缩文件会先解压，解压失败的文件被跳过，
+// 例如 compress/testdata 中故意损坏的 fail-issue5747.bz2。
+// 内容相同的输入只保留第一个，例如 e.txt 和 e.txt.bz2。
+func Load(paths ...string) ([]Input, error) {
+	var inputs []Input
+	seen := make(map[[sha256.Size]byte]bool)
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			data, err := load(path)
+			if errors.Is(err, errCorrupt) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(data)
+			if seen[sum] {
+				return nil
+			}
+			seen[sum] = true
+			name, _ := filepath.Rel(root, path)
+			if name == "." {
+				name = path
+			}
+			inputs = append(inputs, Input{Name: filepath.ToSlash(name), Data: data})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+// errCorrupt 压缩文件无法解压
+var errCorrupt = errors.New("bench: corrupt input")
+
+// load 读取文件的内容，压缩文件返回解压后的内容
+func load(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := codec.DetectReader(bytes.NewReader(b))
+	if errors.Is(err, codec.ErrUnknownFormat) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errCorrupt, path, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", errCorrupt, path, err)
+	}
+	return data, nil
+}
+
+// Result 一个输入使用一种编解码器和压缩级别的测试结果
+//
+// 速度按照未压缩的大小计算，1 MB 为 10^6 字节，与 testing 包的 MB/s 一致。
+// 内存分配为平均每次压缩或解压整个输入的分配次数和字节数。
+type Result struct {
+	Input string `json:"input"`
+	Case
+	Size             int     `json:"size"`
+	CompressedSize   int     `json:"compressed_size"`
+	Ratio            float64 `json:"ratio"`
+	CompressMBps     float64 `json:"compress_mbps"`
+	DecompressMBps   float64 `json:"decompress_mbps"`
+	CompressAllocs   uint64  `json:"compress_allocs"`
+	CompressBytes    uint64  `json:"compress_bytes"`
+	DecompressAllocs uint64  `json:"decompress_allocs"`
+	DecompressBytes  uint64  `json:"decompress_bytes"`
+}
+
+// Measure 使用 c 压缩和解压 in，每个方向至少重复 minTime 的时间
+//
+// 正式计时之前先执行一次，并且检查解压后的内容与原始数据一致。
+func Measure(in Input, c Case, minTime time.Duration) (Result, error) {
+	res := Result{Input: in.Name, Case: c, Size: len(in.Data)}
+
+	var buf bytes.Buffer
+	compress := func() error {
+		buf.Reset()
+		w, err := codec.NewWriter(c.Codec, &buf, c.Level)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(in.Data); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+	if err := compress(); err != nil {
+		return res, fmt.Errorf("%s level %d: %w", c.Codec, c.Level, err)
+	}
+	compressed := append([]byte(nil), buf.Bytes()...)
+	res.CompressedSize = len(compressed)
+	if len(compressed) > 0 {
+		res.Ratio = float64(len(in.Data)) / float64(len(compressed))
+	}
+
+	decompress := func(w io.Writer) error {
+		r, err := codec.NewReader(c.Codec, bytes.NewReader(compressed))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(w, r)
+		return err
+	}
+	var got bytes.Buffer
+	if err := decompress(&got); err != nil || !bytes.Equal(got.Bytes(), in.Data) {
+		return res, fmt.Errorf("%s level %d: %s does not round trip: %v", c.Codec, c.Level, in.Name, err)
+	}
+
+	elapsed, allocs, allocBytes, err := repeat(minTime, compress)
+	if err != nil {
+		return res, err
+	}
+	res.CompressMBps = mbps(len(in.Data), elapsed)
+	res.CompressAllocs, res.CompressBytes = allocs, allocBytes
+	elapsed, allocs, allocBytes, err = repeat(minTime, func() error { return decompress(io.Discard) })
+	if err != nil {
+		return res, err
+	}
+	res.DecompressAllocs, res.DecompressBytes = allocs, allocBytes
+	res.DecompressMBps = mbps(len(in.Data), elapsed)
+	return res, nil
+}
+
+// repeat 重复执行 f 直到经过 minTime，返回平均每次的耗时、内存分配次数和字节数
+func repeat(minTime time.Duration, f func() error) (time.Duration, uint64, uint64, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	n := 0
+	for n == 0 || time.Since(start) < minTime {
+		if err := f(); err != nil {
+			return 0, 0, 0, err
+		}
+		n++
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return elapsed / time.Duration(n), (after.Mallocs - before.Mallocs) / uint64(n), (after.TotalAlloc - before.TotalAlloc) / uint64(n), nil
+}
+
+func mbps(size int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(size) / 1e6 / d.Seconds()
+}
+
+// Run 对每个输入执行每个组合，结果按照输入、组合的顺序排列
+func Run(inputs []Input, cases []Case, minTime time.Duration) ([]Result, error) {
+	results := make([]Result, 0, len(inputs)*len(cases))
+	for _, in := range inputs {
+		for _, c := range cases {
+			res, err := Measure(in, c, minTime)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+// WriteTable 以对齐的表格输出测试结果，没有压缩级别的组合显示为 "-"
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "INPUT\tCODEC\tLEVEL\tSIZE\tCOMPRESSED\tRATIO\tCOMP MB/s\tDECOMP MB/s\tCOMP ALLOCS\tDECOMP ALLOCS\t")
+	for _, r := range results {
+		level := "-"
+		if r.Level != 0 {
+			level = fmt.Sprint(r.Level)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%.3f\t%.1f\t%.1f\t%d\t%d\t\n",
+			r.Input, r.Codec, level, r.Size, r.CompressedSize, r.Ratio,
+			r.CompressMBps, r.DecompressMBps, r.CompressAllocs, r.DecompressAllocs)
+	}
+	return tw.Flush()
+}
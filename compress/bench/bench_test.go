@@ -0,0 +1,67 @@
+package bench_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"standard-library-examples/compress/bench"
+)
+
+func TestLoad(t *testing.T) {
+	inputs, err := bench.Load("../testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]int)
+	for _, in := range inputs {
+		names[in.Name] = len(in.Data)
+	}
+	t.Logf("inputs: %v", names)
+
+	// bz2 文件被解压，与未压缩的文件内容相同时只保留一个
+	if names["Isaac.Newton-Opticks.txt.bz2"] != 567198 || names["pass-sawtooth.bz2"] != 1<<20 {
+		t.Errorf("bz2 inputs were not decompressed")
+	}
+	if _, ok := names["e.txt.bz2"]; ok {
+		t.Errorf("e.txt.bz2 duplicates e.txt/e.txt")
+	}
+	if names["e.txt/e.txt"] != 100003 {
+		t.Errorf("e.txt/e.txt size = %d", names["e.txt/e.txt"])
+	}
+	// 损坏的文件被跳过
+	if _, ok := names["fail-issue5747.bz2"]; ok {
+		t.Errorf("corrupt fail-issue5747.bz2 should be skipped")
+	}
+}
+
+func TestRun(t *testing.T) {
+	text := []byte(strings.Repeat("Four score and seven years ago our fathers brought forth ", 200))
+	inputs := []bench.Input{{Name: "gettysburg", Data: text}}
+	results, err := bench.Run(inputs, bench.DefaultCases(), time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(bench.DefaultCases()) {
+		t.Fatalf("%d results", len(results))
+	}
+	for _, r := range results {
+		if r.Ratio <= 1 || r.CompressMBps <= 0 || r.DecompressMBps <= 0 || r.CompressAllocs == 0 {
+			t.Errorf("%s level %d: %+v", r.Codec, r.Level, r)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := bench.WriteTable(&buf, results[len(results)-2:]); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("\n" + buf.String())
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.Contains(lines[1], " lzw ") {
+		t.Errorf("table:\n%s", buf.String())
+	}
+
+	if _, err := bench.Measure(inputs[0], bench.Case{Codec: "bzip2"}, time.Millisecond); err == nil {
+		t.Errorf("Measure(bzip2) should fail, bzip2 cannot compress")
+	}
+}
@@ -0,0 +1,86 @@
+// compressbench 比较各种编解码器在每个压缩级别下的压缩率、压缩和解压速度以及内存分配
+//
+// 用法：
+//
+//	go run ./compress/bench/cmd/compressbench
+//	go run ./compress/bench/cmd/compressbench -codecs flate,gzip -time 500ms -json file1 dir2
+//
+// 不指定输入时使用 compress/testdata 中的文件，需要在仓库根目录下运行。
+// 测试 flate、gzip、zlib 从 BestSpeed 到 BestCompression 的每个级别，以及 lzw 的 LSB 和 MSB 两种位序。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"standard-library-examples/compress/bench"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print results as JSON")
+	codecs := flag.String("codecs", "", "comma-separated codecs to run, default all")
+	minTime := flag.Duration("time", 100*time.Millisecond, "minimum run time for each measurement")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: compressbench [-json] [-codecs list] [-time d] [files or directories...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("compressbench: ")
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"compress/testdata"}
+	}
+	inputs, err := bench.Load(paths...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		log.Fatal("no inputs found")
+	}
+
+	cases := bench.DefaultCases()
+	if *codecs != "" {
+		cases = filter(cases, strings.Split(*codecs, ","))
+		if len(cases) == 0 {
+			log.Fatalf("no cases match %q", *codecs)
+		}
+	}
+
+	results, err := bench.Run(inputs, cases, *minTime)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	} else {
+		err = bench.WriteTable(os.Stdout, results)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// filter 只保留编解码器名称在 names 中的组合
+func filter(cases []bench.Case, names []string) []bench.Case {
+	keep := make(map[string]bool)
+	for _, name := range names {
+		keep[strings.TrimSpace(name)] = true
+	}
+	var out []bench.Case
+	for _, c := range cases {
+		if keep[c.Codec] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,265 @@
+// Package codec 把 compress 下各个包的构造函数统一为按名称查找的编解码器，并且可以根据魔数自动识别压缩格式
+//
+// 各个包的构造函数签名并不相同，例如 lzw.NewReader(r, lzw.LSB, 8) 需要额外的参数，
+// bzip2 只能解压，gzip.NewReader 在读取头部时就可能返回错误。
+// Codec 把它们统一为 NewReader 和 NewWriter 两个函数，额外的参数在注册时固定下来。
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrUnknownFormat DetectReader 无法识别数据的压缩格式
+	ErrUnknownFormat = errors.New("codec: unknown compression format")
+	// ErrNotFound 没有注册该名称的编解码器
+	ErrNotFound = errors.New("codec: codec not found")
+	// ErrWriteUnsupported 编解码器只能解压，例如标准库中的 bzip2
+	ErrWriteUnsupported = errors.New("codec: compression not supported")
+)
+
+// Codec 一种压缩格式的编解码器
+type Codec struct {
+	Name string
+	// Magic 数据开头的魔数，为空并且 Detect 也为 nil 时，该格式不能被自动识别，例如没有头部的 flate 和 lzw
+	Magic []byte
+	// Detect 魔数不足以识别的格式使用的判断函数，head 为数据开头最多 PeekSize 个字节
+	Detect func(head []byte) bool
+	// NewReader 返回解压 r 的 io.ReadCloser
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	// NewWriter 返回以压缩级别 level 压缩到 w 的 io.WriteCloser，为 nil 表示只能解压；
+	// 没有压缩级别的格式忽略 level
+	NewWriter func(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+// match 判断 head 是否为该格式的数据
+func (c *Codec) match(head []byte) bool {
+	if len(c.Magic) > 0 {
+		return bytes.HasPrefix(head, c.Magic)
+	}
+	return c.Detect != nil && c.Detect(head)
+}
+
+// PeekSize DetectReader 最多预读的字节数，也是 Magic 的最大长度
+const PeekSize = 64
+
+// Registry 按名称和魔数查找编解码器，可以被多个 goroutine 同时使用
+type Registry struct {
+	mu     sync.RWMutex
+	byName map[string]*Codec
+	order  []*Codec // 识别格式时的检查顺序
+}
+
+// NewRegistry 返回一个空的 Registry
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]*Codec)}
+}
+
+// Register 注册编解码器 c，名称已经存在时返回错误
+//
+// 识别格式时，先按照魔数从长到短检查设置了 Magic 的编解码器，再按照注册顺序检查只设置了 Detect 的，
+// 因此自定义的格式只要有魔数，就不会被 zlib 这类只能通过校验规则判断的格式抢先识别。
+func (r *Registry) Register(c Codec) error {
+	if c.Name == "" || c.NewReader == nil {
+		return errors.New("codec: Name and NewReader are required")
+	}
+	if len(c.Magic) > PeekSize {
+		return fmt.Errorf("codec: %s magic longer than %d bytes", c.Name, PeekSize)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byName[c.Name]; ok {
+		return fmt.Errorf("codec: %s already registered", c.Name)
+	}
+	r.byName[c.Name] = &c
+	r.order = append(r.order, &c)
+	sort.SliceStable(r.order, func(i, j int) bool {
+		return len(r.order[i].Magic) > len(r.order[j].Magic)
+	})
+	return nil
+}
+
+// Lookup 返回名称为 name 的编解码器
+func (r *Registry) Lookup(name string) (*Codec, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return c, nil
+}
+
+// Names 按字典序返回所有已注册的名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewReader 使用名称为 name 的编解码器解压 rd
+func (r *Registry) NewReader(name string, rd io.Reader) (io.ReadCloser, error) {
+	c, err := r.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.NewReader(rd)
+}
+
+// NewWriter 使用名称为 name 的编解码器以压缩级别 level 压缩到 w
+func (r *Registry) NewWriter(name string, w io.Writer, level int) (io.WriteCloser, error) {
+	c, err := r.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.NewWriter == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWriteUnsupported, name)
+	}
+	return c.NewWriter(w, level)
+}
+
+// Detect 返回 head 对应的编解码器，无法识别时返回 nil
+func (r *Registry) Detect(head []byte) *Codec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.order {
+		if c.match(head) {
+			return c
+		}
+	}
+	return nil
+}
+
+// DetectReader 通过 bufio 预读 rd 开头的数据识别压缩格式，返回对应的解压器和编解码器
+//
+// 无法识别时返回 ErrUnknownFormat，同时返回原样读取数据的 io.ReadCloser，
+// 调用者可以把数据当作未压缩的内容继续读取。
+func (r *Registry) DetectReader(rd io.Reader) (io.ReadCloser, *Codec, error) {
+	br := bufio.NewReader(rd)
+	head, err := br.Peek(PeekSize)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	c := r.Detect(head)
+	if c == nil {
+		return io.NopCloser(br), nil, ErrUnknownFormat
+	}
+	rc, err := c.NewReader(br)
+	if err != nil {
+		return nil, c, err
+	}
+	return rc, c, nil
+}
+
+// Default 默认的 Registry，包含 flate、gzip、zlib、lzw、lzw-msb 和 bzip2
+var Default = NewRegistry()
+
+// Register 在 Default 中注册编解码器
+func Register(c Codec) error { return Default.Register(c) }
+
+// Lookup 在 Default 中查找编解码器
+func Lookup(name string) (*Codec, error) { return Default.Lookup(name) }
+
+// NewReader 使用 Default 中名称为 name 的编解码器解压 r
+func NewReader(name string, r io.Reader) (io.ReadCloser, error) { return Default.NewReader(name, r) }
+
+// NewWriter 使用 Default 中名称为 name 的编解码器压缩到 w
+func NewWriter(name string, w io.Writer, level int) (io.WriteCloser, error) {
+	return Default.NewWriter(name, w, level)
+}
+
+// DetectReader 使用 Default 识别 r 的压缩格式
+func DetectReader(r io.Reader) (io.ReadCloser, *Codec, error) { return Default.DetectReader(r) }
+
+// isZlib 按照 RFC 1950 判断 zlib 头部：压缩方法为 8，窗口不超过 32KiB，前两个字节组成的整数是 31 的倍数
+//
+// 只有 16 位的校验，以 "x^" 等字符开头的普通文本也会满足，因此排在所有有魔数的格式之后检查。
+func isZlib(head []byte) bool {
+	return len(head) >= 2 && head[0]&0x0f == 8 && head[0]>>4 <= 7 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0
+}
+
+// isBzip2 "BZh" 之后是 '1'~'9' 表示的块大小
+func isBzip2(head []byte) bool {
+	return len(head) >= 4 && string(head[:3]) == "BZh" && head[3] >= '1' && head[3] <= '9'
+}
+
+func init() {
+	builtin := []Codec{
+		{
+			Name: "gzip",
+			// 魔数之后的第三个字节是压缩方法，只有 8 (deflate)
+			Magic: []byte{0x1f, 0x8b, 0x08},
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+			NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+				return gzip.NewWriterLevel(w, level)
+			},
+		},
+		{
+			Name:   "bzip2",
+			Detect: isBzip2,
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return io.NopCloser(bzip2.NewReader(r)), nil
+			},
+		},
+		{
+			Name:   "zlib",
+			Detect: isZlib,
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return zlib.NewReader(r)
+			},
+			NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+				return zlib.NewWriterLevel(w, level)
+			},
+		},
+		{
+			Name: "flate",
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return flate.NewReader(r), nil
+			},
+			NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+				return flate.NewWriter(w, level)
+			},
+		},
+		{
+			Name: "lzw",
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return lzw.NewReader(r, lzw.LSB, 8), nil
+			},
+			NewWriter: func(w io.Writer, _ int) (io.WriteCloser, error) {
+				return lzw.NewWriter(w, lzw.LSB, 8), nil
+			},
+		},
+		{
+			Name: "lzw-msb",
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return lzw.NewReader(r, lzw.MSB, 8), nil
+			},
+			NewWriter: func(w io.Writer, _ int) (io.WriteCloser, error) {
+				return lzw.NewWriter(w, lzw.MSB, 8), nil
+			},
+		},
+	}
+	for _, c := range builtin {
+		if err := Default.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package codec_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"standard-library-examples/compress/codec"
+)
+
+func TestRoundTrip(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 100)
+	for _, name := range codec.Default.Names() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := codec.NewWriter(name, &buf, flate.BestCompression)
+			if errors.Is(err, codec.ErrWriteUnsupported) {
+				t.Skip(err)
+			}
+			if err != nil {
+				t.Fatalf("NewWriter: %v", err)
+			}
+			_, _ = io.WriteString(w, text)
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			r, err := codec.NewReader(name, bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil || string(got) != text {
+				t.Errorf("round trip: %d bytes, %v", len(got), err)
+			}
+
+			// 有魔数或者判断函数的格式可以被自动识别
+			c, _ := codec.Lookup(name)
+			if len(c.Magic) == 0 && c.Detect == nil {
+				return
+			}
+			r, detected, err := codec.DetectReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("DetectReader: %v", err)
+			}
+			if detected.Name != name {
+				t.Errorf("detected %s", detected.Name)
+			}
+			if got, _ := io.ReadAll(r); string(got) != text {
+				t.Errorf("DetectReader: %d bytes", len(got))
+			}
+		})
+	}
+}
+
+func TestDetectTestdata(t *testing.T) {
+	for file, want := range map[string]string{
+		"../testdata/e.txt.gz":          "gzip",
+		"../testdata/e.txt.bz2":         "bzip2",
+		"../testdata/pass-sawtooth.bz2": "bzip2",
+		"../testdata/e.txt/e.txt":       "",
+	} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, c, err := codec.DetectReader(f)
+		switch {
+		case want == "":
+			if !errors.Is(err, codec.ErrUnknownFormat) {
+				t.Errorf("%s: err = %v, want ErrUnknownFormat", file, err)
+			}
+			// 无法识别时仍然可以读取原始数据
+			if b, _ := io.ReadAll(r); !bytes.HasPrefix(b, []byte("2.7182818284")) {
+				t.Errorf("%s: passthrough data = %.20q", file, b)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", file, err)
+		case c.Name != want:
+			t.Errorf("%s: detected %s, want %s", file, c.Name, want)
+		default:
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				t.Errorf("%s: decompress: %v", file, err)
+			}
+		}
+		f.Close()
+	}
+}
+
+// xorCodec 测试用的自定义格式：魔数 "XOR1"，之后每个字节与 0x5a 异或
+var xorCodec = codec.Codec{
+	Name:  "xor",
+	Magic: []byte("XOR1"),
+	NewReader: func(r io.Reader) (io.ReadCloser, error) {
+		magic := make([]byte, 4)
+		if _, err := io.ReadFull(r, magic); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xorReader{r}), nil
+	},
+	NewWriter: func(w io.Writer, _ int) (io.WriteCloser, error) {
+		if _, err := w.Write([]byte("XOR1")); err != nil {
+			return nil, err
+		}
+		return xorWriter{w}, nil
+	},
+}
+
+type xorReader struct{ r io.Reader }
+
+func (x xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	for i := range p[:n] {
+		p[i] ^= 0x5a
+	}
+	return n, err
+}
+
+type xorWriter struct{ w io.Writer }
+
+func (x xorWriter) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	for i := range p {
+		b[i] = p[i] ^ 0x5a
+	}
+	return x.w.Write(b)
+}
+
+func (xorWriter) Close() error { return nil }
+
+func TestRegister(t *testing.T) {
+	reg := codec.NewRegistry()
+	if err := reg.Register(xorCodec); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := reg.Register(xorCodec); err == nil {
+		t.Errorf("duplicate Register should fail")
+	}
+	if _, err := reg.Lookup("gzip"); !errors.Is(err, codec.ErrNotFound) {
+		t.Errorf("new Registry should be empty, Lookup(gzip) err = %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, _ := reg.NewWriter("xor", &buf, 0)
+	_, _ = io.WriteString(w, "secret")
+	_ = w.Close()
+
+	r, c, err := reg.DetectReader(&buf)
+	if err != nil || c.Name != "xor" {
+		t.Fatalf("DetectReader = %v, %v", c, err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "secret" {
+		t.Errorf("xor data = %q", b)
+	}
+
+	if _, err := codec.NewWriter("bzip2", io.Discard, 0); !errors.Is(err, codec.ErrWriteUnsupported) {
+		t.Errorf("bzip2 NewWriter err = %v", err)
+	}
+}
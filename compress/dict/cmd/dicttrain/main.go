@@ -0,0 +1,112 @@
+// dicttrain 从样本文件训练 flate 和 zlib 使用的预设字典
+//
+// 用法：
+//
+//	go run ./compress/dict/cmd/dicttrain -size 4096 -o messages.dict samples/
+//	go run ./compress/dict/cmd/dicttrain -size 1024 -o messages.dict a.json b.json
+//
+// 每个文件是一个样本，参数为目录时使用目录下的所有文件。
+// 训练完成后打印字典的 ID，以及样本不使用字典和使用字典时的压缩率。
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"standard-library-examples/compress/dict"
+)
+
+func main() {
+	size := flag.Int("size", 4096, "dictionary size in bytes, at most 32768")
+	out := flag.String("o", "", "output file for the dictionary")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("dicttrain: ")
+
+	if *out == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: dicttrain [-size n] -o file samples...")
+		os.Exit(2)
+	}
+	samples, err := load(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(samples) == 0 {
+		log.Fatal("no samples found")
+	}
+
+	d := dict.New(dict.Train(samples, *size))
+	if err := os.WriteFile(*out, d.Data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("dictionary %08x: %d bytes from %d samples\n", d.ID, len(d.Data), len(samples))
+
+	plain, err := ratio(samples, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, flate.BestCompression)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	trained, err := ratio(samples, func(w io.Writer) (io.WriteCloser, error) {
+		return d.NewWriter(w, flate.BestCompression)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("ratio without dictionary: %.2f\n", plain)
+	fmt.Printf("ratio with dictionary:    %.2f\n", trained)
+}
+
+// load 读取所有样本文件，目录会被递归遍历
+func load(paths []string) ([][]byte, error) {
+	var samples [][]byte
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, b)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return samples, nil
+}
+
+// ratio 分别压缩每个样本，返回原始大小之和与压缩后大小之和的比值
+//
+// 样本本身就是训练数据，结果会比在新数据上的压缩率偏高，只能作为参考。
+func ratio(samples [][]byte, compress func(w io.Writer) (io.WriteCloser, error)) (float64, error) {
+	var buf bytes.Buffer
+	var total, compressed int
+	for _, s := range samples {
+		buf.Reset()
+		w, err := compress(&buf)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.Write(s); err != nil {
+			return 0, err
+		}
+		if err := w.Close(); err != nil {
+			return 0, err
+		}
+		total += len(s)
+		compressed += buf.Len()
+	}
+	return float64(total) / float64(compressed), nil
+}
@@ -0,0 +1,119 @@
+// Package dict 从样本数据训练 flate 和 zlib 使用的预设字典，并且在压缩数据中记录字典 ID
+//
+// 几百个字节的 JSON 消息单独压缩时，deflate 还没有积累足够的历史数据就结束了，
+// 压缩率往往很差。预设字典相当于把消息中常见的内容预先放进窗口，
+// 例如字段名和固定的取值，压缩时可以直接引用。
+//
+// 解压时必须使用和压缩时完全相同的字典。zlib 格式本身就可以记录字典的 Adler-32 校验和 (DICTID)，
+// flate 没有头部，NewWriter 会在数据开头写入 4 个字节的字典 ID。
+// 两种格式的字典 ID 都是字典内容的 Adler-32 校验和，因此同一个字典在两种格式中的 ID 相同。
+package dict
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/adler32"
+	"io"
+)
+
+// MaxSize deflate 的窗口大小，也是字典的最大有效长度
+const MaxSize = 32 << 10
+
+// Dictionary 预设字典及其 ID
+type Dictionary struct {
+	ID   uint32
+	Data []byte
+}
+
+// New 返回内容为 data 的字典，ID 是 data 的 Adler-32 校验和
+func New(data []byte) *Dictionary {
+	return &Dictionary{ID: adler32.Checksum(data), Data: data}
+}
+
+// UnknownIDError 压缩数据使用的字典不在提供的字典中
+type UnknownIDError struct {
+	ID uint32
+}
+
+func (e *UnknownIDError) Error() string {
+	return fmt.Sprintf("dict: unknown dictionary ID %08x", e.ID)
+}
+
+// find 按照 ID 查找字典
+func find(id uint32, dicts []*Dictionary) (*Dictionary, error) {
+	for _, d := range dicts {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, &UnknownIDError{ID: id}
+}
+
+// NewWriter 返回使用字典 d 以压缩级别 level 压缩到 w 的 flate.Writer，
+// 数据开头先写入 4 个字节大端序的字典 ID
+func (d *Dictionary) NewWriter(w io.Writer, level int) (*flate.Writer, error) {
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], d.ID)
+	if _, err := w.Write(id[:]); err != nil {
+		return nil, err
+	}
+	return flate.NewWriterDict(w, level, d.Data)
+}
+
+// NewReader 读取 Dictionary.NewWriter 写入的字典 ID，从 dicts 中选择对应的字典解压 r
+//
+// 找不到字典时返回 *UnknownIDError。
+func NewReader(r io.Reader, dicts ...*Dictionary) (io.ReadCloser, error) {
+	var id [4]byte
+	if _, err := io.ReadFull(r, id[:]); err != nil {
+		return nil, err
+	}
+	d, err := find(binary.BigEndian.Uint32(id[:]), dicts)
+	if err != nil {
+		return nil, err
+	}
+	return flate.NewReaderDict(r, d.Data), nil
+}
+
+// NewZlibWriter 返回使用字典 d 以压缩级别 level 压缩到 w 的 zlib.Writer，
+// 字典 ID 记录在 zlib 头部的 DICTID 中
+func (d *Dictionary) NewZlibWriter(w io.Writer, level int) (*zlib.Writer, error) {
+	return zlib.NewWriterLevelDict(w, level, d.Data)
+}
+
+// zlibFDICT zlib 头部第二个字节中表示使用了预设字典的标志位
+const zlibFDICT = 0x20
+
+// NewZlibReader 解压 zlib 数据，根据头部的 DICTID 从 dicts 中选择字典
+//
+// 头部没有设置 FDICT 时按照普通的 zlib 数据解压，DICTID 不在 dicts 中时返回 *UnknownIDError。
+// 为了预读头部，r 不是 bufio.Reader 时会被包装，可能从 r 中多读取 zlib 数据之后的内容。
+func NewZlibReader(r io.Reader, dicts ...*Dictionary) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(2)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if head[1]&zlibFDICT == 0 {
+		return zlib.NewReader(br)
+	}
+	head, err = br.Peek(6)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	d, err := find(binary.BigEndian.Uint32(head[2:]), dicts)
+	if err != nil {
+		return nil, err
+	}
+	return zlib.NewReaderDict(br, d.Data)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,226 @@
+package dict_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"standard-library-examples/compress/dict"
+)
+
+// messages 生成 n 条服务之间传递的小 JSON 消息，字段名和部分取值固定，其余内容随机
+func messages(seed int64, n int) [][]byte {
+	rnd := rand.New(rand.NewSource(seed))
+	events := []string{"order.created", "order.paid", "order.shipped", "user.login", "user.logout"}
+	regions := []string{"cn-north-1", "cn-east-2", "ap-southeast-1"}
+	base := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	msgs := make([][]byte, n)
+	for i := range msgs {
+		m := map[string]interface{}{
+			"event":      events[rnd.Intn(len(events))],
+			"request_id": fmt.Sprintf("%016x", rnd.Uint64()),
+			"user_id":    rnd.Intn(1000000),
+			"region":     regions[rnd.Intn(len(regions))],
+			"timestamp":  base.Add(time.Duration(rnd.Intn(86400)) * time.Second).Format(time.RFC3339),
+			"client":     map[string]string{"platform": "android", "version": fmt.Sprintf("3.%d.%d", rnd.Intn(10), rnd.Intn(10))},
+			"amount":     float64(rnd.Intn(100000)) / 100,
+			"currency":   "CNY",
+		}
+		msgs[i], _ = json.Marshal(m)
+	}
+	return msgs
+}
+
+func TestTrain(t *testing.T) {
+	samples := messages(1, 500)
+	d := dict.Train(samples, 1024)
+	if len(d) == 0 || len(d) > 1024 {
+		t.Fatalf("dictionary size = %d", len(d))
+	}
+	for _, s := range []string{`"request_id":"`, `"currency":"CNY"`, `"platform":"android"`} {
+		if !bytes.Contains(d, []byte(s)) {
+			t.Errorf("dictionary does not contain %s", s)
+		}
+	}
+	t.Logf("dictionary: %q", d)
+
+	if d := dict.Train(samples, 100000); len(d) > dict.MaxSize {
+		t.Errorf("dictionary size %d exceeds MaxSize", len(d))
+	}
+	if d := dict.Train(nil, 1024); len(d) != 0 {
+		t.Errorf("Train(nil) = %q", d)
+	}
+}
+
+func TestFlate(t *testing.T) {
+	d := dict.New(dict.Train(messages(1, 500), 2048))
+	other := dict.New([]byte("unrelated dictionary"))
+	msg := messages(2, 1)[0]
+
+	var buf bytes.Buffer
+	w, err := d.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(msg)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := dict.NewReader(bytes.NewReader(buf.Bytes()), other, d)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("round trip = %q, %v", got, err)
+	}
+
+	var unknown *dict.UnknownIDError
+	if _, err := dict.NewReader(bytes.NewReader(buf.Bytes()), other); !errors.As(err, &unknown) || unknown.ID != d.ID {
+		t.Errorf("NewReader without dictionary err = %v", err)
+	}
+}
+
+func TestZlib(t *testing.T) {
+	d := dict.New(dict.Train(messages(1, 500), 2048))
+	msg := messages(2, 1)[0]
+
+	var buf bytes.Buffer
+	w, err := d.NewZlibWriter(&buf, zlib.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write(msg)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 标准库的 zlib.NewReaderDict 也可以解压，说明字典 ID 和 DICTID 一致
+	zr, err := zlib.NewReaderDict(bytes.NewReader(buf.Bytes()), d.Data)
+	if err != nil {
+		t.Fatalf("zlib.NewReaderDict: %v", err)
+	}
+	if got, _ := io.ReadAll(zr); !bytes.Equal(got, msg) {
+		t.Errorf("zlib.NewReaderDict = %q", got)
+	}
+
+	r, err := dict.NewZlibReader(bytes.NewReader(buf.Bytes()), d)
+	if err != nil {
+		t.Fatalf("NewZlibReader: %v", err)
+	}
+	if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("round trip = %q, %v", got, err)
+	}
+	var unknown *dict.UnknownIDError
+	if _, err := dict.NewZlibReader(bytes.NewReader(buf.Bytes())); !errors.As(err, &unknown) {
+		t.Errorf("NewZlibReader without dictionary err = %v", err)
+	}
+
+	// 没有使用字典的 zlib 数据按照普通数据解压
+	buf.Reset()
+	plain := zlib.NewWriter(&buf)
+	_, _ = plain.Write(msg)
+	_ = plain.Close()
+	r, err = dict.NewZlibReader(&buf, d)
+	if err != nil {
+		t.Fatalf("NewZlibReader(plain): %v", err)
+	}
+	if got, _ := io.ReadAll(r); !bytes.Equal(got, msg) {
+		t.Errorf("plain round trip = %q", got)
+	}
+}
+
+// samplesDir 真实样本所在的目录，每个文件是一条消息，例如：
+//
+//	go test ./compress/dict -bench=Ratio -samples=/path/to/messages
+//
+// 为空时使用 messages 生成的消息
+var samplesDir = flag.String("samples", "", "directory of sample messages for BenchmarkRatio, one message per file")
+
+// loadSamples 按照文件名的顺序读取 dir 中的文件，交替分为训练集和测试集
+func loadSamples(b *testing.B, dir string) (train, test [][]byte) {
+	b.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(train) <= len(test) {
+			train = append(train, data)
+		} else {
+			test = append(test, data)
+		}
+	}
+	if len(test) == 0 {
+		b.Fatalf("%s: need at least 2 sample files", dir)
+	}
+	return train, test
+}
+
+// BenchmarkRatio 分别压缩每条消息，比较不使用字典和使用不同大小字典的压缩率
+//
+// 训练和测试使用不同的消息，避免测试消息本身出现在字典中。
+// ratio 为原始大小与压缩后大小之比，flate 的结果包含 4 个字节的字典 ID。
+// 生成的消息结构单一，字典的效果比真实数据更好，评估实际的收益请通过 -samples 使用真实的样本。
+func BenchmarkRatio(b *testing.B) {
+	test := messages(2, 200)
+	train := messages(1, 1000)
+	if *samplesDir != "" {
+		train, test = loadSamples(b, *samplesDir)
+	}
+	var total int
+	for _, m := range test {
+		total += len(m)
+	}
+
+	run := func(b *testing.B, compress func(w io.Writer) (io.WriteCloser, error)) {
+		var buf bytes.Buffer
+		var compressed int
+		for i := 0; i < b.N; i++ {
+			compressed = 0
+			for _, m := range test {
+				buf.Reset()
+				w, err := compress(&buf)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_, _ = w.Write(m)
+				_ = w.Close()
+				compressed += buf.Len()
+			}
+		}
+		b.SetBytes(int64(total))
+		b.ReportMetric(float64(total)/float64(compressed), "ratio")
+	}
+
+	b.Run("none", func(b *testing.B) {
+		run(b, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, flate.BestCompression)
+		})
+	})
+	for _, size := range []int{256, 1024, 4096} {
+		d := dict.New(dict.Train(train, size))
+		b.Run(fmt.Sprintf("dict-%d", size), func(b *testing.B) {
+			run(b, func(w io.Writer) (io.WriteCloser, error) {
+				return d.NewWriter(w, flate.BestCompression)
+			})
+		})
+	}
+}
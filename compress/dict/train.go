@@ -0,0 +1,142 @@
+package dict
+
+import "container/heap"
+
+const (
+	// gramLen 统计频率时使用的 n-gram 长度，deflate 的最短匹配是 3 个字节，
+	// 太短的片段收益很小，太长的片段又很难在不同的样本中重复出现
+	gramLen = 6
+	// maxSegment 单个片段的最大长度，避免几乎相同的样本把整个字典占满
+	maxSegment = 256
+)
+
+// Train 从样本中找出高频的子串，生成不超过 size 个字节的字典
+//
+// 先统计每个 n-gram 出现在多少个样本中，再把样本中连续的高频 n-gram 合并为片段，
+// 片段的得分是其中每个 n-gram 出现的样本数之和，从高到低挑选；
+// 片段中已经被字典覆盖的 n-gram 不再计分，因此重复的内容不会占用字典的空间。
+// deflate 引用越近的数据编码越短，所以得分最高的片段放在字典的末尾。
+//
+// 超过 MaxSize 的 size 会被截断为 MaxSize，样本太少或者没有重复内容时返回的字典可能比 size 短。
+func Train(samples [][]byte, size int) []byte {
+	if size > MaxSize {
+		size = MaxSize
+	}
+	if size <= 0 {
+		return nil
+	}
+
+	// df 每个 n-gram 出现在多少个样本中，同一个样本中重复出现只计一次
+	df := make(map[string]int)
+	for _, s := range samples {
+		seen := make(map[string]bool)
+		for i := 0; i+gramLen <= len(s); i++ {
+			g := string(s[i : i+gramLen])
+			if !seen[g] {
+				seen[g] = true
+				df[g]++
+			}
+		}
+	}
+	minCount := 2
+	if len(samples) < 2 {
+		minCount = 1
+	}
+
+	scores := make(map[string]int)
+	for _, s := range samples {
+		for i := 0; i+gramLen <= len(s); {
+			if df[string(s[i:i+gramLen])] < minCount {
+				i++
+				continue
+			}
+			j, score := i, 0
+			for j+gramLen <= len(s) && j-i < maxSegment-gramLen {
+				n := df[string(s[j:j+gramLen])]
+				if n < minCount {
+					break
+				}
+				score += n
+				j++
+			}
+			seg := string(s[i : j-1+gramLen])
+			if score > scores[seg] {
+				scores[seg] = score
+			}
+			i = j
+		}
+	}
+
+	h := make(segmentHeap, 0, len(scores))
+	for seg, score := range scores {
+		h = append(h, segment{seg, score})
+	}
+	heap.Init(&h)
+
+	// 已经放进字典的 n-gram 不再计分，避免只有少数字符不同的片段反复入选
+	covered := make(map[string]bool)
+	var chosen []string
+	total := 0
+	for h.Len() > 0 && total+gramLen <= size {
+		top := heap.Pop(&h).(segment)
+		score := 0
+		for i := 0; i+gramLen <= len(top.s); i++ {
+			if g := top.s[i : i+gramLen]; !covered[g] {
+				score += df[g]
+			}
+		}
+		if score == 0 {
+			continue
+		}
+		if score < top.score && h.Len() > 0 && score < h[0].score {
+			// 得分下降后不再是最高的，放回堆中等待重新比较
+			top.score = score
+			heap.Push(&h, top)
+			continue
+		}
+		seg := top.s
+		if total+len(seg) > size {
+			seg = seg[:size-total]
+		}
+		for i := 0; i+gramLen <= len(seg); i++ {
+			covered[seg[i:i+gramLen]] = true
+		}
+		chosen = append(chosen, seg)
+		total += len(seg)
+	}
+
+	d := make([]byte, 0, total)
+	for i := len(chosen) - 1; i >= 0; i-- {
+		d = append(d, chosen[i]...)
+	}
+	return d
+}
+
+// segment 候选片段及其得分
+type segment struct {
+	s     string
+	score int
+}
+
+// segmentHeap 按得分从高到低排列的候选片段，得分相同时按字典序，保证结果确定
+type segmentHeap []segment
+
+func (h segmentHeap) Len() int { return len(h) }
+
+func (h segmentHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+	return h[i].s < h[j].s
+}
+
+func (h segmentHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *segmentHeap) Push(x interface{}) { *h = append(*h, x.(segment)) }
+
+func (h *segmentHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
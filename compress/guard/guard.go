@@ -0,0 +1,152 @@
+// Package guard 限制解压的输出，防止很小的恶意输入解压出几乎无限的数据 (decompression bomb)
+//
+// 几 KB 的 gzip 或 bzip2 数据可以解压出 GB 级别的内容，直接把不可信的上传交给 io.ReadAll 或 io.Copy
+// 会耗尽内存或者磁盘。Reader 包装任意一种解压器，统计压缩前后的字节数，
+// 超过输出大小或者压缩率的上限时返回 *LimitError。
+package guard
+
+import (
+	"fmt"
+	"io"
+
+	"standard-library-examples/compress/codec"
+)
+
+// DefaultRatioThreshold Limits.RatioThreshold 为 0 时使用的值
+const DefaultRatioThreshold = 1 << 20
+
+// Limits 解压的限制，为 0 的字段表示不限制
+type Limits struct {
+	// MaxSize 解压后最多的字节数
+	MaxSize int64
+	// MaxRatio 解压后的字节数与已经读取的压缩数据字节数之比的上限
+	MaxRatio float64
+	// RatioThreshold 输出超过这个字节数之后才检查 MaxRatio，为 0 时使用 DefaultRatioThreshold。
+	// 解压刚开始时压缩率的波动很大，全是空白的小文件也可能有很高的压缩率，不应该被拒绝。
+	RatioThreshold int64
+}
+
+// LimitKind 超过的限制类型
+type LimitKind int
+
+const (
+	LimitSize  LimitKind = iota + 1 // 解压后的字节数超过 MaxSize
+	LimitRatio                      // 压缩率超过 MaxRatio
+)
+
+func (k LimitKind) String() string {
+	switch k {
+	case LimitSize:
+		return "size"
+	case LimitRatio:
+		return "ratio"
+	}
+	return fmt.Sprintf("LimitKind(%d)", int(k))
+}
+
+// LimitError 解压的输出超过了限制
+type LimitError struct {
+	Kind   LimitKind
+	Limits Limits
+	// Size 超过限制时已经从解压器读取的字节数，超过 MaxSize 时包含多读的部分
+	Size int64
+	// Compressed 超过限制时已经读取的压缩数据字节数，
+	// 解压器通常会预读，因此可能比实际解压使用的多
+	Compressed int64
+}
+
+func (e *LimitError) Error() string {
+	if e.Kind == LimitRatio {
+		return fmt.Sprintf("guard: expansion ratio exceeds %g (%d bytes from %d compressed)", e.Limits.MaxRatio, e.Size, e.Compressed)
+	}
+	return fmt.Sprintf("guard: decompressed size exceeds %d bytes", e.Limits.MaxSize)
+}
+
+// countReader 统计读取的字节数
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Reader 在限制之内读取解压后的数据
+//
+// 超过限制之后 Read 返回 *LimitError，之后的每次 Read 都返回同一个错误。
+// MaxSize 是精确的：解压后恰好为 MaxSize 个字节的数据可以完整读取，多一个字节就会返回错误，
+// 并且返回给调用者的数据不会超过 MaxSize。
+type Reader struct {
+	in     *countReader
+	r      io.Reader
+	limits Limits
+	n      int64
+	err    error
+}
+
+// NewReader 使用 open 返回的解压器解压 src，并且按照 limits 限制输出
+//
+// open 接收的是统计字节数之后的 src，任何解压器都可以使用，例如：
+//
+//	guard.NewReader(f, limits, func(r io.Reader) (io.Reader, error) {
+//		return gzip.NewReader(r)
+//	})
+//
+// 解压器实现了 io.Closer 时，Reader.Close 会关闭它。
+func NewReader(src io.Reader, limits Limits, open func(io.Reader) (io.Reader, error)) (*Reader, error) {
+	if limits.RatioThreshold == 0 {
+		limits.RatioThreshold = DefaultRatioThreshold
+	}
+	in := &countReader{r: src}
+	r, err := open(in)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{in: in, r: r, limits: limits}, nil
+}
+
+// NewCodecReader 使用 compress/codec 中名称为 name 的编解码器解压 src，并且按照 limits 限制输出
+func NewCodecReader(src io.Reader, name string, limits Limits) (*Reader, error) {
+	return NewReader(src, limits, func(r io.Reader) (io.Reader, error) {
+		return codec.NewReader(name, r)
+	})
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	max := r.limits.MaxSize
+	if max > 0 && int64(len(p)) > max-r.n+1 {
+		// 多读一个字节，用来区分恰好达到上限和超过上限
+		p = p[:max-r.n+1]
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if max > 0 && r.n > max {
+		err := r.fail(LimitSize)
+		n -= int(r.n - max)
+		r.n = max
+		return n, err
+	}
+	if lim := r.limits; lim.MaxRatio > 0 && r.n > lim.RatioThreshold && float64(r.n) > lim.MaxRatio*float64(r.in.n) {
+		return n, r.fail(LimitRatio)
+	}
+	return n, err
+}
+
+func (r *Reader) fail(kind LimitKind) error {
+	r.err = &LimitError{Kind: kind, Limits: r.limits, Size: r.n, Compressed: r.in.n}
+	return r.err
+}
+
+// Close 关闭解压器，不会关闭 src
+func (r *Reader) Close() error {
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package guard_test
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"standard-library-examples/compress/guard"
+)
+
+// bomb 返回 size 个零字节压缩成的 gzip 数据，压缩率约为 1000
+func bomb(t *testing.T, size int64) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if _, err := io.CopyN(zw, zeros{}, size); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func gunzip(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+
+func TestMaxSize(t *testing.T) {
+	data := bomb(t, 10<<20)
+	r, err := guard.NewReader(bytes.NewReader(data), guard.Limits{MaxSize: 1 << 20}, gunzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.Copy(io.Discard, r)
+	var le *guard.LimitError
+	if !errors.As(err, &le) || le.Kind != guard.LimitSize {
+		t.Fatalf("err = %v, want size LimitError", err)
+	}
+	if n != 1<<20 {
+		t.Errorf("read %d bytes, want exactly MaxSize", n)
+	}
+	if _, err := r.Read(make([]byte, 1)); err != le {
+		t.Errorf("Read after limit err = %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	// 恰好等于上限的数据可以完整读取
+	r, _ = guard.NewReader(bytes.NewReader(bomb(t, 1<<20)), guard.Limits{MaxSize: 1 << 20}, gunzip)
+	if n, err := io.Copy(io.Discard, r); err != nil || n != 1<<20 {
+		t.Errorf("exact size: %d bytes, %v", n, err)
+	}
+}
+
+func TestMaxRatio(t *testing.T) {
+	data := bomb(t, 10<<20)
+	r, _ := guard.NewReader(bytes.NewReader(data), guard.Limits{MaxRatio: 100}, gunzip)
+	n, err := io.Copy(io.Discard, r)
+	var le *guard.LimitError
+	if !errors.As(err, &le) || le.Kind != guard.LimitRatio {
+		t.Fatalf("err = %v, want ratio LimitError", err)
+	}
+	t.Logf("stopped after %d bytes: %v", n, err)
+	if n >= 10<<20 || le.Size <= guard.DefaultRatioThreshold {
+		t.Errorf("read %d bytes, LimitError.Size %d", n, le.Size)
+	}
+
+	// 小于 RatioThreshold 的输出不检查压缩率
+	small := bomb(t, 512<<10)
+	r, _ = guard.NewReader(bytes.NewReader(small), guard.Limits{MaxRatio: 100}, gunzip)
+	if n, err := io.Copy(io.Discard, r); err != nil || n != 512<<10 {
+		t.Errorf("below threshold: %d bytes, %v", n, err)
+	}
+}
+
+// TestTestdata 正常的文件在合理的限制下可以完整解压，压缩率很高的 pass-sawtooth.bz2 被拒绝
+func TestTestdata(t *testing.T) {
+	limits := guard.Limits{MaxSize: 64 << 20, MaxRatio: 100, RatioThreshold: 64 << 10}
+	for _, tc := range []struct {
+		file  string
+		codec string
+		kind  guard.LimitKind // 为 0 表示应该成功
+	}{
+		{"../testdata/e.txt.gz", "gzip", 0},
+		{"../testdata/e.txt.bz2", "bzip2", 0},
+		{"../testdata/Isaac.Newton-Opticks.txt.bz2", "bzip2", 0},
+		{"../testdata/pass-sawtooth.bz2", "bzip2", guard.LimitRatio},
+	} {
+		f, err := os.Open(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := guard.NewCodecReader(f, tc.codec, limits)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.file, err)
+		}
+		_, err = io.Copy(io.Discard, r)
+		var le *guard.LimitError
+		switch {
+		case tc.kind == 0 && err != nil:
+			t.Errorf("%s: %v", tc.file, err)
+		case tc.kind != 0 && (!errors.As(err, &le) || le.Kind != tc.kind):
+			t.Errorf("%s: err = %v, want %s LimitError", tc.file, err, tc.kind)
+		}
+		r.Close()
+		f.Close()
+	}
+}
+
+// TestCorrupt 解压器本身的错误原样返回，而不是 LimitError
+func TestCorrupt(t *testing.T) {
+	f, err := os.Open("../testdata/fail-issue5747.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, _ := guard.NewReader(f, guard.Limits{MaxSize: 1 << 20, MaxRatio: 100}, func(r io.Reader) (io.Reader, error) {
+		return bzip2.NewReader(r), nil
+	})
+	_, err = io.Copy(io.Discard, r)
+	var se bzip2.StructuralError
+	if !errors.As(err, &se) {
+		t.Errorf("err = %v, want bzip2.StructuralError", err)
+	}
+}
+
+func ExampleNewReader() {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(make([]byte, 4096))
+	_ = zw.Close()
+
+	r, err := guard.NewReader(&buf, guard.Limits{MaxSize: 1000}, func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer r.Close()
+	n, err := io.Copy(io.Discard, r)
+	fmt.Println(n, err)
+	// Output: 1000 guard: decompressed size exceeds 1000 bytes
+}
@@ -0,0 +1,214 @@
+package gzindex
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrFormat 索引数据不是 WriteTo 写入的格式
+var ErrFormat = errors.New("gzindex: invalid index format")
+
+const (
+	indexMagic   = "GZIX"
+	indexVersion = 1
+)
+
+// Point 一个重启点，即某个 gzip 成员的起始位置，成员内部没有重启点
+type Point struct {
+	CompressedOffset   int64
+	UncompressedOffset int64
+}
+
+// Index gzip 文件的重启点，按照偏移量递增排列
+type Index struct {
+	Points         []Point
+	Size           int64 // 解压后的总大小
+	CompressedSize int64
+}
+
+// BuildIndex 读取整个 gzip 流，以每个成员的起始位置作为重启点建立索引
+//
+// 单个成员的文件 (例如 gzip(1) 生成的) 只会得到一个重启点，虽然仍然可以用 NewReader 读取，
+// 但是 Seek 到任何位置都需要从头解压，只有本包 Writer 生成的多成员文件才能高效地随机读取。
+func BuildIndex(r io.Reader) (*Index, error) {
+	idx := &Index{}
+	mr := NewMemberReader(r)
+	for {
+		m, err := mr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		idx.Points = append(idx.Points, Point{CompressedOffset: m.Offset, UncompressedOffset: idx.Size})
+		if _, err := io.Copy(io.Discard, mr); err != nil {
+			return nil, err
+		}
+		idx.Size += m.Size
+		idx.CompressedSize = m.Offset + m.CompressedSize
+	}
+	if len(idx.Points) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return idx, nil
+}
+
+// WriteTo 以紧凑的二进制格式写入索引：
+//
+//	"GZIX" | 版本 | 重启点数量 | 总大小 | 压缩后总大小 | 每个重启点与前一个的差值（压缩、未压缩）
+//
+// 除魔数和版本之外都是 uvarint，每个重启点通常只占 4~6 个字节。
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	buf := append([]byte(indexMagic), indexVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(idx.Points)))
+	buf = binary.AppendUvarint(buf, uint64(idx.Size))
+	buf = binary.AppendUvarint(buf, uint64(idx.CompressedSize))
+	var prev Point
+	for _, p := range idx.Points {
+		buf = binary.AppendUvarint(buf, uint64(p.CompressedOffset-prev.CompressedOffset))
+		buf = binary.AppendUvarint(buf, uint64(p.UncompressedOffset-prev.UncompressedOffset))
+		prev = p
+	}
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// ReadIndex 读取 WriteTo 写入的索引
+func ReadIndex(r io.Reader) (*Index, error) {
+	br := bufio.NewReader(r)
+	head := make([]byte, len(indexMagic)+1)
+	if _, err := io.ReadFull(br, head); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if string(head[:len(indexMagic)]) != indexMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrFormat, head[:len(indexMagic)])
+	}
+	if head[len(indexMagic)] != indexVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrFormat, head[len(indexMagic)])
+	}
+
+	var fields [3]uint64
+	for i := range fields {
+		v, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+		fields[i] = v
+	}
+	count := fields[0]
+	idx := &Index{Size: int64(fields[1]), CompressedSize: int64(fields[2])}
+	var prev Point
+	for i := uint64(0); i < count; i++ {
+		dc, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+		du, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+		p := Point{CompressedOffset: prev.CompressedOffset + int64(dc), UncompressedOffset: prev.UncompressedOffset + int64(du)}
+		if p.CompressedOffset > idx.CompressedSize || p.UncompressedOffset > idx.Size {
+			return nil, fmt.Errorf("%w: point %d out of range", ErrFormat, i)
+		}
+		idx.Points = append(idx.Points, p)
+		prev = p
+	}
+	if len(idx.Points) == 0 || idx.Points[0] != (Point{}) {
+		return nil, fmt.Errorf("%w: missing first point", ErrFormat)
+	}
+	return idx, nil
+}
+
+// Reader 根据索引随机读取 gzip 文件，实现了 io.ReadSeeker
+//
+// Seek 只记录位置，下一次 Read 时从不超过该位置的最近重启点开始解压，并丢弃之前的数据；
+// 如果新位置就在当前解压位置之后不远，则直接向后解压。顺序读取时不需要重新定位。
+// 定位的代价取决于成员的大小，见 BuildIndex。
+type Reader struct {
+	ra   io.ReaderAt
+	idx  *Index
+	pos  int64
+	zr   *gzip.Reader
+	zpos int64 // zr 下一个字节对应的未压缩偏移量，zr 为 nil 时无效
+}
+
+// NewReader 返回按照 idx 随机读取 ra 的 Reader
+func NewReader(ra io.ReaderAt, idx *Index) *Reader {
+	return &Reader{ra: ra, idx: idx}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.pos >= r.idx.Size {
+		return 0, io.EOF
+	}
+	if r.zr == nil || r.zpos != r.pos {
+		if err := r.reposition(); err != nil {
+			return 0, err
+		}
+	}
+	if remain := r.idx.Size - r.pos; int64(len(p)) > remain {
+		p = p[:remain]
+	}
+	n, err := r.zr.Read(p)
+	r.pos += int64(n)
+	r.zpos = r.pos
+	if err == io.EOF && r.pos < r.idx.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Seek 设置下一次 Read 的未压缩偏移量
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.idx.Size
+	default:
+		return 0, errors.New("gzindex: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("gzindex: negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// reposition 让 zr 的位置与 pos 一致
+func (r *Reader) reposition() error {
+	i := sort.Search(len(r.idx.Points), func(i int) bool {
+		return r.idx.Points[i].UncompressedOffset > r.pos
+	}) - 1
+	p := r.idx.Points[i]
+
+	// 当前解压位置在目标之前，并且比最近的重启点更近时，直接向后解压
+	if r.zr == nil || r.zpos > r.pos || r.zpos < p.UncompressedOffset {
+		sr := io.NewSectionReader(r.ra, p.CompressedOffset, r.idx.CompressedSize-p.CompressedOffset)
+		var err error
+		if r.zr == nil {
+			r.zr, err = gzip.NewReader(sr)
+		} else {
+			err = r.zr.Reset(sr)
+		}
+		if err != nil {
+			r.zr = nil
+			return err
+		}
+		r.zpos = p.UncompressedOffset
+	}
+	n, err := io.CopyN(io.Discard, r.zr, r.pos-r.zpos)
+	r.zpos += n
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package gzindex_test
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	"standard-library-examples/compress/gzindex"
+)
+
+// opticks 读取 compress/testdata 中的 Isaac.Newton-Opticks.txt
+func opticks(tb testing.TB) []byte {
+	tb.Helper()
+	f, err := os.Open("../testdata/Isaac.Newton-Opticks.txt.bz2")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	text, err := io.ReadAll(bzip2.NewReader(f))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return text
+}
+
+// seekable 使用 gzindex.Writer 按照 memberSize 切分压缩 data
+func seekable(tb testing.TB, data []byte, memberSize int64) ([]byte, *gzindex.Index) {
+	tb.Helper()
+	var buf bytes.Buffer
+	zw, err := gzindex.NewWriter(&buf, gzip.DefaultCompression, memberSize)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	zw.Header.Name = "opticks.txt"
+	if _, err := zw.Write(data); err != nil {
+		tb.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes(), zw.Index()
+}
+
+func TestIndex(t *testing.T) {
+	text := opticks(t)
+	data, idx := seekable(t, text, 64<<10)
+
+	// 切分后仍然是标准的 gzip
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(zr); !bytes.Equal(got, text) || zr.Name != "opticks.txt" {
+		t.Fatalf("gzip.NewReader: %d bytes, name %q", len(got), zr.Name)
+	}
+
+	wantPoints := (len(text) + 64<<10 - 1) / (64 << 10)
+	if len(idx.Points) != wantPoints || idx.Size != int64(len(text)) || idx.CompressedSize != int64(len(data)) {
+		t.Errorf("index: %d points, size %d, compressed %d", len(idx.Points), idx.Size, idx.CompressedSize)
+	}
+	built, err := gzindex.BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	if !reflect.DeepEqual(built, idx) {
+		t.Errorf("BuildIndex = %+v, want %+v", built, idx)
+	}
+
+	var buf bytes.Buffer
+	if _, err := idx.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%d points encoded in %d bytes", len(idx.Points), buf.Len())
+	read, err := gzindex.ReadIndex(&buf)
+	if err != nil {
+		t.Fatalf("ReadIndex: %v", err)
+	}
+	if !reflect.DeepEqual(read, idx) {
+		t.Errorf("ReadIndex = %+v, want %+v", read, idx)
+	}
+
+	if _, err := gzindex.ReadIndex(bytes.NewReader([]byte("GZIP\x01"))); !errors.Is(err, gzindex.ErrFormat) {
+		t.Errorf("ReadIndex(bad magic) err = %v", err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	text := opticks(t)
+	data, idx := seekable(t, text, 50000)
+	r := gzindex.NewReader(bytes.NewReader(data), idx)
+
+	rnd := rand.New(rand.NewSource(1))
+	buf := make([]byte, 3000)
+	for i := 0; i < 100; i++ {
+		off := rnd.Int63n(int64(len(text)))
+		if _, err := r.Seek(off, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatalf("ReadFull at %d: %v", off, err)
+		}
+		if !bytes.Equal(buf[:n], text[off:off+int64(n)]) {
+			t.Fatalf("data at %d does not match", off)
+		}
+	}
+
+	// 从结尾向前定位，读到结尾返回 io.EOF
+	if _, err := r.Seek(-10, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	tail, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(tail, text[len(text)-10:]) {
+		t.Errorf("tail = %q, %v", tail, err)
+	}
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("negative Seek should fail")
+	}
+}
+
+// TestSingleMember compress/gzip 生成的单成员文件只有一个重启点，仍然可以正确地随机读取
+func TestSingleMember(t *testing.T) {
+	text := opticks(t)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(text)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := gzindex.BuildIndex(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	if len(idx.Points) != 1 || idx.Points[0] != (gzindex.Point{}) {
+		t.Errorf("Points = %+v, want only the start of the file", idx.Points)
+	}
+
+	r := gzindex.NewReader(bytes.NewReader(buf.Bytes()), idx)
+	for _, off := range []int64{int64(len(text)) / 2, 100, int64(len(text)) - 50} {
+		if _, err := r.Seek(off, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, 50)
+		if _, err := io.ReadFull(r, got); err != nil || !bytes.Equal(got, text[off:off+50]) {
+			t.Errorf("data at %d = %q, %v", off, got, err)
+		}
+	}
+}
+
+// BenchmarkSeek 比较从头解压和从最近的重启点解压读取文件末尾的数据
+func BenchmarkSeek(b *testing.B) {
+	text := bytes.Repeat(opticks(b), 8)
+	data, idx := seekable(b, text, 1<<20)
+	off := int64(len(text) - 4096)
+	buf := make([]byte, 4096)
+
+	b.Run("gzip", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			zr, _ := gzip.NewReader(bytes.NewReader(data))
+			_, _ = io.CopyN(io.Discard, zr, off)
+			_, _ = io.ReadFull(zr, buf)
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r := gzindex.NewReader(bytes.NewReader(data), idx)
+			_, _ = r.Seek(off, io.SeekStart)
+			_, _ = io.ReadFull(r, buf)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+// Package gzindex 逐个读取多成员 gzip，并通过成员边界建立索引，实现按未压缩偏移量随机读取
+//
+// deflate 流中间的位置依赖前面 32KiB 的数据和比特级的解码状态，compress/flate 无法从中间恢复解码，
+// 因此可以重新开始解压的位置只有 gzip 成员的边界，本包不会像 zlib 的 zran 示例那样在成员内部记录窗口和比特位置。
+//
+// 这意味着只有多成员的 gzip 才能高效地随机读取。gzip(1) 和 compress/gzip 的 Writer
+// 生成的文件只有一个成员，索引中只有文件开头一个重启点，每次向后 Seek 都要从头解压。
+// 需要随机读取的文件应该使用本包的 Writer 按照固定大小切分为多个成员，
+// 切分后的文件仍然是标准的 gzip，gzip.NewReader 和 gunzip 都可以直接解压。
+package gzindex
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+)
+
+// Member 一个 gzip 成员
+type Member struct {
+	gzip.Header
+	Offset int64 // 成员在压缩数据中的起始位置
+	// CompressedSize 和 Size 在成员的内容读到 io.EOF 之后才会被填入
+	CompressedSize int64
+	Size           int64
+}
+
+// countReader 统计已经读取的字节数
+//
+// 实现了 io.ByteReader，gzip.Reader 直接使用它而不是再包装一层 bufio.Reader，
+// 因此读完一个成员时，n 正好是下一个成员的起始位置。
+type countReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// MemberReader 依次读取 gzip 流中的每个成员，用法与 tar.Reader 相同
+//
+// gzip.Reader 默认把多个成员拼接为一个流，头部只能看到第一个成员的；
+// MemberReader 关闭了 Multistream，并且在成员之间通过 Reset 复用同一个 gzip.Reader。
+type MemberReader struct {
+	cr  *countReader
+	zr  *gzip.Reader
+	cur *Member
+	err error
+}
+
+// NewMemberReader 返回读取 r 中 gzip 成员的 MemberReader
+func NewMemberReader(r io.Reader) *MemberReader {
+	return &MemberReader{cr: &countReader{r: bufio.NewReader(r)}}
+}
+
+// Next 前进到下一个成员，当前成员中未读取的数据会被丢弃，没有更多成员时返回 io.EOF
+func (m *MemberReader) Next() (*Member, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.cur != nil {
+		if _, err := io.Copy(io.Discard, m); err != nil {
+			m.err = err
+			return nil, err
+		}
+	}
+
+	offset := m.cr.n
+	var err error
+	if m.zr == nil {
+		m.zr, err = gzip.NewReader(m.cr)
+	} else {
+		err = m.zr.Reset(m.cr)
+	}
+	if err != nil {
+		m.cur, m.err = nil, err
+		return nil, err
+	}
+	m.zr.Multistream(false)
+	m.cur = &Member{Header: m.zr.Header, Offset: offset}
+	return m.cur, nil
+}
+
+// Read 读取当前成员解压后的内容
+func (m *MemberReader) Read(p []byte) (int, error) {
+	if m.cur == nil {
+		if m.err != nil {
+			return 0, m.err
+		}
+		return 0, io.EOF
+	}
+	n, err := m.zr.Read(p)
+	m.cur.Size += int64(n)
+	if err == io.EOF {
+		m.cur.CompressedSize = m.cr.n - m.cur.Offset
+	}
+	return n, err
+}
@@ -0,0 +1,108 @@
+package gzindex_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"standard-library-examples/compress/gzindex"
+)
+
+// member 压缩一个带有头部信息的 gzip 成员
+func member(t *testing.T, hdr gzip.Header, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Header = hdr
+	if _, err := zw.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMemberReader(t *testing.T) {
+	members := []struct {
+		hdr  gzip.Header
+		body string
+	}{
+		{gzip.Header{Name: "a.txt", Comment: "first", ModTime: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}, "hello "},
+		{gzip.Header{Name: "b.txt"}, ""},
+		{gzip.Header{Name: "c.txt", Comment: "third", ModTime: time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)}, "world"},
+	}
+	var data []byte
+	offsets := []int64{0}
+	for _, m := range members {
+		data = append(data, member(t, m.hdr, m.body)...)
+		offsets = append(offsets, int64(len(data)))
+	}
+
+	mr := gzindex.NewMemberReader(bytes.NewReader(data))
+	for i := 0; ; i++ {
+		m, err := mr.Next()
+		if err == io.EOF {
+			if i != len(members) {
+				t.Errorf("read %d members, want %d", i, len(members))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		want := members[i]
+		if m.Name != want.hdr.Name || m.Comment != want.hdr.Comment || !m.ModTime.Equal(want.hdr.ModTime) || m.Offset != offsets[i] {
+			t.Errorf("member %d: name %q comment %q mtime %v offset %d", i, m.Name, m.Comment, m.ModTime, m.Offset)
+		}
+		body, err := io.ReadAll(mr)
+		if err != nil || string(body) != want.body {
+			t.Errorf("member %d body = %q, %v", i, body, err)
+		}
+		if m.Size != int64(len(want.body)) || m.Offset+m.CompressedSize != offsets[i+1] {
+			t.Errorf("member %d: size %d compressed %d", i, m.Size, m.CompressedSize)
+		}
+	}
+
+	// 不读取内容时 Next 直接跳到下一个成员
+	mr = gzindex.NewMemberReader(bytes.NewReader(data))
+	var names []string
+	for {
+		m, err := mr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, m.Name)
+	}
+	if len(names) != 3 || names[2] != "c.txt" {
+		t.Errorf("names = %v", names)
+	}
+}
+
+// TestMemberReaderSingle 普通的单成员 gzip 文件
+func TestMemberReaderSingle(t *testing.T) {
+	f, err := os.Open("../testdata/e.txt.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, _ := f.Stat()
+
+	mr := gzindex.NewMemberReader(f)
+	m, err := mr.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if _, err := io.Copy(io.Discard, mr); err != nil {
+		t.Fatal(err)
+	}
+	if m.Size != 100003 || m.CompressedSize != fi.Size() {
+		t.Errorf("size = %d, compressed = %d", m.Size, m.CompressedSize)
+	}
+	if _, err := mr.Next(); err != io.EOF {
+		t.Errorf("second Next err = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,120 @@
+package gzindex
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// countWriter 统计已经写入的字节数
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Writer 每写入 MemberSize 个字节就开始一个新的 gzip 成员，同时记录索引
+//
+// 成员越小随机读取越快，但是每个成员都从空窗口开始压缩，并且有 18 个字节以上的头部和尾部，
+// 压缩率会略有下降。1 MiB 左右的成员通常是合适的折中。
+type Writer struct {
+	// Header 只写入第一个成员，需要在第一次调用 Write 之前设置
+	Header gzip.Header
+
+	cw         *countWriter
+	zw         *gzip.Writer
+	level      int
+	memberSize int64
+	active     bool  // 当前成员是否已经开始
+	n          int64 // 当前成员已经写入的字节数
+	idx        Index
+	closed     bool
+}
+
+// NewWriter 返回以压缩级别 level 写入 w 的 Writer，每个成员最多包含 memberSize 个未压缩的字节
+func NewWriter(w io.Writer, level int, memberSize int64) (*Writer, error) {
+	if memberSize <= 0 {
+		return nil, errors.New("gzindex: member size must be positive")
+	}
+	cw := &countWriter{w: w}
+	zw, err := gzip.NewWriterLevel(cw, level)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{cw: cw, zw: zw, level: level, memberSize: memberSize}, nil
+}
+
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.closed {
+		return 0, errors.New("gzindex: write to closed writer")
+	}
+	written := 0
+	for len(p) > 0 {
+		if !z.active {
+			z.begin()
+		}
+		chunk := p
+		if remain := z.memberSize - z.n; int64(len(chunk)) > remain {
+			chunk = chunk[:remain]
+		}
+		n, err := z.zw.Write(chunk)
+		written += n
+		z.n += int64(n)
+		z.idx.Size += int64(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+		if z.n == z.memberSize {
+			if err := z.end(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close 结束最后一个成员，不会关闭底层的 io.Writer
+func (z *Writer) Close() error {
+	if z.closed {
+		return nil
+	}
+	z.closed = true
+	if !z.active && len(z.idx.Points) == 0 {
+		// 没有写入任何数据时仍然输出一个空的成员
+		z.begin()
+	}
+	if z.active {
+		return z.end()
+	}
+	return nil
+}
+
+// Index 返回已经写入的数据的索引，Close 之后才包含完整的内容
+func (z *Writer) Index() *Index {
+	idx := z.idx
+	idx.Points = append([]Point(nil), z.idx.Points...)
+	return &idx
+}
+
+func (z *Writer) begin() {
+	if len(z.idx.Points) > 0 {
+		z.zw.Reset(z.cw)
+	} else {
+		z.zw.Header = z.Header
+	}
+	z.idx.Points = append(z.idx.Points, Point{CompressedOffset: z.cw.n, UncompressedOffset: z.idx.Size})
+	z.active, z.n = true, 0
+}
+
+func (z *Writer) end() error {
+	z.active = false
+	err := z.zw.Close()
+	z.idx.CompressedSize = z.cw.n
+	return err
+}
@@ -0,0 +1,293 @@
+// Package pgzip 使用多个 goroutine 并行压缩 gzip
+//
+// 输入被切分为固定大小的块，每个块使用前面最近 32KiB 的数据作为预设字典单独压缩，
+// 块之间通过 flate 的同步刷新按字节对齐，拼接起来就是一个完整的 deflate 流。
+// 因此输出是标准的单个 gzip 成员，gzip.NewReader 可以直接解压，
+// 压缩率只比 gzip.Writer 略低：每个块的开头无法引用更早的数据，并且多出一个 5 字节的同步标记。
+package pgzip
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const (
+	// DefaultBlockSize Options.BlockSize 为 0 时使用的块大小
+	DefaultBlockSize = 1 << 20
+	// dictSize deflate 的窗口大小，也是每个块预设字典的最大长度
+	dictSize = 32 << 10
+)
+
+// ErrClosed 向已经关闭的 Writer 写入
+var ErrClosed = errors.New("pgzip: write to closed writer")
+
+// Options 并行压缩的选项，零值表示使用默认值
+type Options struct {
+	// Level 压缩级别，与 gzip.NewWriterLevel 相同，0 表示 gzip.DefaultCompression
+	Level int
+	// BlockSize 每个块的大小，默认为 DefaultBlockSize；块越小并行度越高，但是压缩率越低
+	BlockSize int
+	// Concurrency 最多同时压缩的块数，默认为 runtime.GOMAXPROCS(0)，
+	// 内存占用大约为 2 * Concurrency * BlockSize
+	Concurrency int
+}
+
+// Writer 并行压缩的 gzip Writer，用法与 gzip.Writer 相同
+//
+// Header 中的字段需要在第一次调用 Write 或 Close 之前设置。
+type Writer struct {
+	gzip.Header
+	w           io.Writer
+	level       int
+	blockSize   int
+	concurrency int
+
+	started bool
+	closed  bool
+	buf     []byte // 正在填充的块
+	dict    []byte // 已经写入的最后 32KiB 数据，作为下一个块的字典
+	digest  uint32
+	size    uint32
+
+	pool  sync.Pool
+	queue chan *block
+	done  chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// block 一个等待压缩的块，压缩结果按照提交的顺序从 out 中取出
+type block struct {
+	data []byte
+	dict []byte
+	out  chan *bytes.Buffer
+}
+
+// NewWriter 返回向 w 写入 gzip 数据的 Writer，opts 为 nil 时全部使用默认值
+func NewWriter(w io.Writer, opts *Options) (*Writer, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	z := &Writer{
+		Header:      gzip.Header{OS: 255}, // 与 gzip.Writer 相同，OS 为 unknown
+		w:           w,
+		level:       opts.Level,
+		blockSize:   opts.BlockSize,
+		concurrency: opts.Concurrency,
+	}
+	if z.level == 0 {
+		z.level = gzip.DefaultCompression
+	}
+	if z.level < gzip.HuffmanOnly || z.level > gzip.BestCompression {
+		return nil, fmt.Errorf("pgzip: invalid compression level: %d", z.level)
+	}
+	if z.blockSize == 0 {
+		z.blockSize = DefaultBlockSize
+	}
+	if z.blockSize < 0 {
+		return nil, fmt.Errorf("pgzip: invalid block size: %d", z.blockSize)
+	}
+	if z.concurrency <= 0 {
+		z.concurrency = runtime.GOMAXPROCS(0)
+	}
+	z.pool.New = func() any { return make([]byte, 0, z.blockSize) }
+	return z, nil
+}
+
+// Write 把 p 写入当前块，块写满后交给后台压缩；已经有块写入失败时返回该错误
+func (z *Writer) Write(p []byte) (int, error) {
+	if err := z.start(); err != nil {
+		return 0, err
+	}
+	z.digest = crc32.Update(z.digest, crc32.IEEETable, p)
+	z.size += uint32(len(p))
+
+	n := 0
+	for len(p) > 0 {
+		if z.buf == nil {
+			z.buf = z.pool.Get().([]byte)[:0]
+		}
+		m := copy(z.buf[len(z.buf):cap(z.buf)], p)
+		z.buf = z.buf[:len(z.buf)+m]
+		p = p[m:]
+		n += m
+		if len(z.buf) == cap(z.buf) {
+			z.dispatch()
+		}
+	}
+	return n, z.error()
+}
+
+// Close 压缩剩余的数据，写入结束块和 gzip 尾部，不会关闭底层的 io.Writer
+func (z *Writer) Close() error {
+	if z.closed {
+		return z.error()
+	}
+	if err := z.start(); err != nil {
+		return err
+	}
+	z.closed = true
+	if len(z.buf) > 0 {
+		z.dispatch()
+	}
+	close(z.queue)
+	<-z.done
+	if err := z.error(); err != nil {
+		return err
+	}
+
+	// 前面的块都以同步刷新结束，最后补上一个空的结束块
+	var tail bytes.Buffer
+	fw, err := flate.NewWriter(&tail, z.level)
+	if err != nil {
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[:4], z.digest)
+	binary.LittleEndian.PutUint32(trailer[4:], z.size)
+	tail.Write(trailer[:])
+	_, err = z.w.Write(tail.Bytes())
+	return err
+}
+
+// start 在第一次写入时写入 gzip 头部并启动按顺序输出的 goroutine
+func (z *Writer) start() error {
+	if z.closed {
+		return ErrClosed
+	}
+	if z.started {
+		// 写入头部失败时没有启动 writeLoop
+		return z.error()
+	}
+	z.started = true
+	if err := z.writeHeader(); err != nil {
+		z.setError(err)
+		return err
+	}
+	z.queue = make(chan *block, z.concurrency)
+	z.done = make(chan struct{})
+	go z.writeLoop()
+	return nil
+}
+
+// dispatch 把当前块交给后台压缩，正在压缩的块达到 Concurrency 时阻塞
+func (z *Writer) dispatch() {
+	b := &block{data: z.buf, dict: z.dict, out: make(chan *bytes.Buffer, 1)}
+	z.buf = nil
+
+	// 下一个块的字典由当前字典和本块的数据拼接而成，最多保留 32KiB；
+	// 总是分配新的切片，因为 b.dict 仍然被正在压缩的块使用
+	dict := append(append([]byte(nil), z.dict...), b.data...)
+	if len(dict) > dictSize {
+		dict = dict[len(dict)-dictSize:]
+	}
+	z.dict = append([]byte(nil), dict...)
+
+	z.queue <- b
+	go z.compress(b)
+}
+
+func (z *Writer) compress(b *block) {
+	out := new(bytes.Buffer)
+	out.Grow(len(b.data) / 2)
+	fw, err := flate.NewWriterDict(out, z.level, b.dict)
+	if err == nil {
+		_, err = fw.Write(b.data)
+	}
+	if err == nil {
+		// 同步刷新使输出按字节对齐，并且不设置结束标记，后面的块可以直接拼接
+		err = fw.Flush()
+	}
+	if err != nil {
+		z.setError(err)
+	}
+	z.pool.Put(b.data[:0])
+	b.out <- out
+}
+
+// writeLoop 按照提交的顺序把压缩好的块写入 w
+func (z *Writer) writeLoop() {
+	defer close(z.done)
+	for b := range z.queue {
+		out := <-b.out
+		if z.error() != nil {
+			continue
+		}
+		if _, err := z.w.Write(out.Bytes()); err != nil {
+			z.setError(err)
+		}
+	}
+}
+
+func (z *Writer) setError(err error) {
+	z.mu.Lock()
+	if z.err == nil {
+		z.err = err
+	}
+	z.mu.Unlock()
+}
+
+func (z *Writer) error() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.err
+}
+
+// writeHeader 按照 RFC 1952 写入 gzip 头部，Name 和 Comment 必须能用 Latin-1 表示
+func (z *Writer) writeHeader() error {
+	hdr := []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, z.OS}
+	if z.ModTime.Unix() > 0 {
+		binary.LittleEndian.PutUint32(hdr[4:8], uint32(z.ModTime.Unix()))
+	}
+	switch z.level {
+	case gzip.BestCompression:
+		hdr[8] = 2
+	case gzip.BestSpeed:
+		hdr[8] = 4
+	}
+	if z.Extra != nil {
+		hdr[3] |= 0x04
+		hdr = binary.LittleEndian.AppendUint16(hdr, uint16(len(z.Extra)))
+		hdr = append(hdr, z.Extra...)
+	}
+	for _, field := range []struct {
+		flag  byte
+		value string
+	}{{0x08, z.Name}, {0x10, z.Comment}} {
+		if field.value == "" {
+			continue
+		}
+		latin1, err := toLatin1(field.value)
+		if err != nil {
+			return err
+		}
+		hdr[3] |= field.flag
+		hdr = append(append(hdr, latin1...), 0)
+	}
+	_, err := z.w.Write(hdr)
+	return err
+}
+
+// toLatin1 把 s 转换为 Latin-1 编码，gzip 头部中的字符串不能包含 0
+func toLatin1(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r == 0 || r > 0xff {
+			return nil, fmt.Errorf("pgzip: non-Latin-1 header string: %q", s)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
@@ -0,0 +1,177 @@
+package pgzip_test
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"standard-library-examples/compress/pgzip"
+)
+
+// opticks 读取 compress/testdata 中的 Isaac.Newton-Opticks.txt，重复 n 次
+func opticks(tb testing.TB, n int) []byte {
+	tb.Helper()
+	f, err := os.Open("../testdata/Isaac.Newton-Opticks.txt.bz2")
+	if err != nil {
+		tb.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	text, err := io.ReadAll(bzip2.NewReader(f))
+	if err != nil {
+		tb.Fatalf("ReadAll: %v", err)
+	}
+	return bytes.Repeat(text, n)
+}
+
+// compress 使用 pgzip 压缩 data，每次写入 chunk 个字节
+func compress(t *testing.T, data []byte, chunk int, opts *pgzip.Options) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := pgzip.NewWriter(&buf, opts)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for len(data) > 0 {
+		n := chunk
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := zw.Write(data[:n]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		data = data[n:]
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// decompress 只读取一个 gzip 成员，确认之后没有多余的数据
+func decompress(t *testing.T, data []byte) (*gzip.Header, []byte) {
+	t.Helper()
+	br := bytes.NewReader(data)
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	zr.Multistream(false)
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if br.Len() != 0 {
+		t.Errorf("%d bytes after the first gzip member", br.Len())
+	}
+	return &zr.Header, got
+}
+
+func TestWriter(t *testing.T) {
+	text := opticks(t, 1)
+	tests := []struct {
+		size  int
+		chunk int
+		opts  pgzip.Options
+	}{
+		{0, 1, pgzip.Options{}},
+		{1, 1, pgzip.Options{}},
+		{len(text), 4096, pgzip.Options{}},
+		// 块比字典小，字典需要跨越多个块
+		{len(text), 777, pgzip.Options{BlockSize: 1000, Concurrency: 3}},
+		// 块大小的整数倍，最后一个块为空
+		{64 << 10, 64 << 10, pgzip.Options{BlockSize: 16 << 10, Level: gzip.BestSpeed}},
+		{len(text), 1 << 20, pgzip.Options{BlockSize: 50 << 10, Concurrency: 1, Level: gzip.HuffmanOnly}},
+		{len(text), 1 << 20, pgzip.Options{BlockSize: 50 << 10, Level: gzip.BestCompression}},
+	}
+	for _, tt := range tests {
+		name := strconv.Itoa(tt.size) + "/" + strconv.Itoa(tt.opts.BlockSize) + "/" + strconv.Itoa(tt.opts.Level)
+		t.Run(name, func(t *testing.T) {
+			data := text[:tt.size]
+			_, got := decompress(t, compress(t, data, tt.chunk, &tt.opts))
+			if !bytes.Equal(got, data) {
+				t.Errorf("round trip: got %d bytes, want %d", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestWriterHeader(t *testing.T) {
+	var buf bytes.Buffer
+	zw, _ := pgzip.NewWriter(&buf, nil)
+	zw.Name = "a-new-hope.txt"
+	zw.Comment = "an epic space opera by George Lucas"
+	zw.ModTime = time.Date(1977, time.May, 25, 0, 0, 0, 0, time.UTC)
+	zw.Extra = []byte("extra")
+	_, _ = zw.Write([]byte("A long time ago in a galaxy far, far away..."))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zw.Write([]byte("more")); err != pgzip.ErrClosed {
+		t.Errorf("Write after Close err = %v", err)
+	}
+
+	hdr, body := decompress(t, buf.Bytes())
+	if hdr.Name != zw.Name || hdr.Comment != zw.Comment || !hdr.ModTime.Equal(zw.ModTime) || string(hdr.Extra) != "extra" {
+		t.Errorf("header = %+v", hdr)
+	}
+	if string(body) != "A long time ago in a galaxy far, far away..." {
+		t.Errorf("body = %q", body)
+	}
+
+	zw, _ = pgzip.NewWriter(io.Discard, nil)
+	zw.Name = "名字.txt"
+	if err := zw.Close(); err == nil {
+		t.Errorf("non-Latin-1 name should fail")
+	}
+	if _, err := pgzip.NewWriter(io.Discard, &pgzip.Options{Level: 10}); err == nil {
+		t.Errorf("invalid level should fail")
+	}
+}
+
+// TestWriterRatio 压缩率与 gzip.Writer 相近
+func TestWriterRatio(t *testing.T) {
+	data := opticks(t, 4)
+	var std bytes.Buffer
+	zw := gzip.NewWriter(&std)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+
+	parallel := compress(t, data, len(data), &pgzip.Options{BlockSize: 256 << 10})
+	if ratio := float64(len(parallel)) / float64(std.Len()); ratio > 1.01 {
+		t.Errorf("pgzip output %d bytes, gzip %d bytes (%.3fx)", len(parallel), std.Len(), ratio)
+	}
+}
+
+// 输入为 56 份 Opticks，约 31 MiB。只有一个 CPU 时 pgzip 没有加速，还要额外承担分块和字典的开销：
+//
+//	BenchmarkWriter/gzip            3   513844969 ns/op   61.81 MB/s
+//	BenchmarkWriter/pgzip/256KiB    3   568477086 ns/op   55.87 MB/s
+//	BenchmarkWriter/pgzip/1024KiB   3   541248588 ns/op   58.68 MB/s
+//
+// 多核机器上各个块并行压缩，吞吐量随 Concurrency 增加，需要在目标机器上重新测量。
+func BenchmarkWriter(b *testing.B) {
+	data := opticks(b, 56)
+	b.Run("gzip", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			zw := gzip.NewWriter(io.Discard)
+			_, _ = zw.Write(data)
+			_ = zw.Close()
+		}
+	})
+	for _, blockSize := range []int{256 << 10, 1 << 20} {
+		b.Run("pgzip/"+strconv.Itoa(blockSize>>10)+"KiB", func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				zw, _ := pgzip.NewWriter(io.Discard, &pgzip.Options{BlockSize: blockSize})
+				_, _ = zw.Write(data)
+				_ = zw.Close()
+			}
+		})
+	}
+}
@@ -1,23 +1,19 @@
 package testing_test
 
 import (
-	"fmt"
 	"reflect"
 	"testing"
-)
 
-func Sum(numbers []int) int {
-	sum := 0
-	for _, number := range numbers {
-		sum += number
-	}
-	return sum
-}
+	"standard-library-examples/testing/numeric"
+)
 
 func TestSum(t *testing.T) {
 	t.Run("collection of 5 numbers", func(t *testing.T) {
 		numbers := []int{1, 2, 3, 4, 5}
-		got := Sum(numbers)
+		got, err := numeric.Sum(numbers)
+		if err != nil {
+			t.Fatalf("numeric.Sum err: %v", err)
+		}
 		want := 15
 		if want != got {
 			t.Errorf("got '%d' want '%d' given, '%v'", got, want, numbers)
@@ -26,7 +22,10 @@ func TestSum(t *testing.T) {
 
 	t.Run("collection of any size", func(t *testing.T) {
 		numbers := []int{1, 2, 3}
-		got := Sum(numbers)
+		got, err := numeric.Sum(numbers)
+		if err != nil {
+			t.Fatalf("numeric.Sum err: %v", err)
+		}
 		want := 6
 		if want != got {
 			t.Errorf("got '%d' want '%d' given, '%v'", got, want, numbers)
@@ -34,25 +33,12 @@ func TestSum(t *testing.T) {
 	})
 }
 
-func SumAllTails(numbersToSum ...[]int) []int {
-	var sums []int
-	fmt.Println(numbersToSum)
-	for _, numbers := range numbersToSum {
-		if len(numbers) == 0 {
-			sums = append(sums, 0)
-		} else {
-			tail := numbers[1:]
-			fmt.Println(tail)
-			sums = append(sums, Sum(tail))
-		}
-	}
-
-	return sums
-}
-
 func TestSumAll(t *testing.T) {
 	t.Run("make the sums of some slices", func(t *testing.T) {
-		got := SumAllTails([]int{1, 2}, []int{0, 9})
+		got, err := numeric.SumAllTails([]int{1, 2}, []int{0, 9})
+		if err != nil {
+			t.Fatalf("numeric.SumAllTails err: %v", err)
+		}
 		want := []int{2, 9}
 
 		if !reflect.DeepEqual(got, want) {
@@ -61,11 +47,21 @@ func TestSumAll(t *testing.T) {
 	})
 
 	t.Run("safely sum empty slices", func(t *testing.T) {
-		got := SumAllTails([]int{}, []int{3, 4, 5})
+		got, err := numeric.SumAllTails([]int{}, []int{3, 4, 5})
+		if err != nil {
+			t.Fatalf("numeric.SumAllTails err: %v", err)
+		}
 		want := []int{0, 9}
 
 		if !reflect.DeepEqual(got, want) {
 			t.Errorf("got %v want %v", got, want)
 		}
 	})
+
+	t.Run("report overflow", func(t *testing.T) {
+		_, err := numeric.SumAllTails([]int8{0, 100, 100})
+		if err == nil {
+			t.Errorf("want overflow error")
+		}
+	})
 }
@@ -2,8 +2,11 @@ package testing_test
 
 import (
 	"math/rand"
+	"strconv"
 	"testing"
 	"time"
+
+	"standard-library-examples/testing/fibonacci"
 )
 
 // 写法：
@@ -16,6 +19,7 @@ import (
 // -cpu 指定运行cpu核数，默认1核，例：-cpu=2,4,6,8;
 // -benchmem 显示内存分配情况，例：-benchmem;
 
+// fib 递归实现，时间复杂度 O(2^n)，作为其他实现的对照
 func fib(n int) int {
 	if n == 0 || n == 1 {
 		return n
@@ -23,14 +27,47 @@ func fib(n int) int {
 	return fib(n-2) + fib(n-1)
 }
 
-// go test testing/benchmark_test.go -bench=BenchmarkFib
+// go test ./testing -bench=BenchmarkFib -benchmem
 func BenchmarkFib(b *testing.B) {
-	// 模拟耗时准备任务
-	time.Sleep(3 * time.Second)
-	// 重置定时器
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		fib(30)
+	const n = 30
+
+	b.Run("recursive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fib(n)
+		}
+	})
+	b.Run("iterative", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = fibonacci.Iterative(n)
+		}
+	})
+	b.Run("matrix", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = fibonacci.Matrix(n)
+		}
+	})
+	b.Run("memo", func(b *testing.B) {
+		// 每次都使用新的缓存，否则测量的只是一次 map 查找
+		for i := 0; i < b.N; i++ {
+			_, _ = fibonacci.NewMemo().Fib(n)
+		}
+	})
+	b.Run("big", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = fibonacci.Big(n)
+		}
+	})
+}
+
+// BenchmarkFibBig n 超出 int64 范围时只能使用 math/big
+func BenchmarkFibBig(b *testing.B) {
+	for _, n := range []int{100, 10000, 1000000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = fibonacci.Big(n)
+			}
+		})
 	}
 }
 
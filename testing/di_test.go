@@ -2,13 +2,15 @@ package testing
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"testing"
+
+	"standard-library-examples/testing/greeter"
 )
 
+// Greet 依赖 io.Writer 而不是具体的输出目标，测试时可以注入 bytes.Buffer
 func Greet(w io.Writer, name string) {
-	_, _ = fmt.Fprintf(w, "Hello, %s", name)
+	_ = greeter.New().Greet(w, greeter.DefaultLanguage, name)
 }
 
 func TestGreet(t *testing.T) {
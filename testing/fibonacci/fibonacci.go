@@ -0,0 +1,171 @@
+package fibonacci
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"math/bits"
+	"sync"
+
+	"standard-library-examples/testing/numeric"
+)
+
+// ErrNegative n 为负数
+var ErrNegative = errors.New("fibonacci: negative n")
+
+// Iterative 通过循环计算第 n 个斐波那契数，时间复杂度 O(n)
+//
+// 结果超出 int 的范围时返回 numeric.ErrOverflow，更大的 n 请使用 Big
+func Iterative(n int) (int, error) {
+	if n < 0 {
+		return 0, ErrNegative
+	}
+	a, b := 0, 1
+	for i := 0; i < n; i++ {
+		next, err := numeric.Add(a, b)
+		if err != nil && i < n-1 {
+			return 0, err
+		}
+		a, b = b, next
+	}
+	return a, nil
+}
+
+// matrix 2x2 矩阵 [[a, b], [c, d]]
+type matrix [4]int
+
+// mul 计算 m * o，任意一步溢出都会返回错误
+func (m matrix) mul(o matrix) (matrix, error) {
+	var r matrix
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			x, err := numeric.Mul(m[i*2], o[j])
+			if err != nil {
+				return r, err
+			}
+			y, err := numeric.Mul(m[i*2+1], o[2+j])
+			if err != nil {
+				return r, err
+			}
+			if r[i*2+j], err = numeric.Add(x, y); err != nil {
+				return r, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// Matrix 通过矩阵快速幂计算第 n 个斐波那契数，时间复杂度 O(log n)
+//
+// [[1, 1], [1, 0]] 的 n 次幂为 [[F(n+1), F(n)], [F(n), F(n-1)]]，
+// 这里只计算 n-1 次幂，避免 F(n) 还在范围内时 F(n+1) 已经溢出
+func Matrix(n int) (int, error) {
+	if n < 0 {
+		return 0, ErrNegative
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	n--
+	result := matrix{1, 0, 0, 1}
+	base := matrix{1, 1, 1, 0}
+	var err error
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			if result, err = result.mul(base); err != nil {
+				return 0, err
+			}
+		}
+		if n > 1 {
+			if base, err = base.mul(base); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return result[0], nil
+}
+
+// Memo 带缓存的实现，可以被多个 goroutine 同时使用
+//
+// 缓存从小到大依次填充，而不是按照定义递归，否则每个 n 都要占用一层调用栈，
+// 很大的 n 会在返回 ErrOverflow 之前耗尽栈空间，并且这种崩溃无法 recover。
+type Memo struct {
+	mu    sync.Mutex
+	cache []int // cache[i] 为 F(i)
+}
+
+// NewMemo 返回一个只包含 F(0) 和 F(1) 的 Memo
+func NewMemo() *Memo {
+	return &Memo{cache: []int{0, 1}}
+}
+
+// Fib 计算第 n 个斐波那契数，已经计算过的结果直接从缓存返回
+//
+// 结果超出 int 的范围时返回 numeric.ErrOverflow，填充缓存最多只需要计算到 F(92)
+func (m *Memo) Fib(n int) (int, error) {
+	if n < 0 {
+		return 0, ErrNegative
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.cache) <= n {
+		k := len(m.cache)
+		v, err := numeric.Add(m.cache[k-2], m.cache[k-1])
+		if err != nil {
+			return 0, err
+		}
+		m.cache = append(m.cache, v)
+	}
+	return m.cache[n], nil
+}
+
+// Big 使用 math/big 计算第 n 个斐波那契数，n 不受 int64 范围的限制
+//
+// 使用快速倍增公式：
+//
+//	F(2k)   = F(k) * (2*F(k+1) - F(k))
+//	F(2k+1) = F(k)^2 + F(k+1)^2
+func Big(n int) (*big.Int, error) {
+	if n < 0 {
+		return nil, ErrNegative
+	}
+	a, b := big.NewInt(0), big.NewInt(1)
+	if n == 0 {
+		return a, nil
+	}
+	t1, t2 := new(big.Int), new(big.Int)
+	// 从最高位开始，每一步把 k 翻倍，当前位为 1 时再加 1
+	for bit := 1 << (bits.Len(uint(n)) - 1); bit > 0; bit >>= 1 {
+		// t1 = F(2k), t2 = F(2k+1)
+		t1.Lsh(b, 1).Sub(t1, a).Mul(t1, a)
+		t2.Mul(a, a)
+		b.Mul(b, b).Add(b, t2)
+		a.Set(t1)
+		if n&bit != 0 {
+			a.Add(a, b)
+			a, b = b, a
+		}
+	}
+	return a, nil
+}
+
+// Generate 返回一个按顺序产生斐波那契数的 channel
+//
+// 数列只在接收方读取时才会计算，ctx 取消后 channel 会被关闭
+func Generate(ctx context.Context) <-chan *big.Int {
+	ch := make(chan *big.Int)
+	go func() {
+		defer close(ch)
+		a, b := big.NewInt(0), big.NewInt(1)
+		for {
+			select {
+			case ch <- new(big.Int).Set(a):
+			case <-ctx.Done():
+				return
+			}
+			a.Add(a, b)
+			a, b = b, a
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,90 @@
+package fibonacci_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"standard-library-examples/testing/fibonacci"
+	"standard-library-examples/testing/numeric"
+)
+
+var first = []int{0, 1, 1, 2, 3, 5, 8, 13, 21, 34, 55, 89, 144}
+
+func TestFibonacci(t *testing.T) {
+	memo := fibonacci.NewMemo()
+	funcs := map[string]func(int) (int, error){
+		"Iterative": fibonacci.Iterative,
+		"Matrix":    fibonacci.Matrix,
+		"Memo":      memo.Fib,
+	}
+
+	for name, fib := range funcs {
+		for n, want := range first {
+			got, err := fib(n)
+			if err != nil || got != want {
+				t.Errorf("%s(%d) = %d, %v, want %d", name, n, got, err, want)
+			}
+		}
+
+		// F(92) 是 int64 能表示的最大斐波那契数
+		got, err := fib(92)
+		if err != nil || got != 7540113804746346429 {
+			t.Errorf("%s(92) = %d, %v", name, got, err)
+		}
+		if _, err := fib(93); !errors.Is(err, numeric.ErrOverflow) {
+			t.Errorf("%s(93) err = %v, want ErrOverflow", name, err)
+		}
+		// 很大的 n 同样返回 ErrOverflow，而不是耗尽调用栈
+		if _, err := fib(50_000_000); !errors.Is(err, numeric.ErrOverflow) {
+			t.Errorf("%s(50000000) err = %v, want ErrOverflow", name, err)
+		}
+		if _, err := fib(-1); !errors.Is(err, fibonacci.ErrNegative) {
+			t.Errorf("%s(-1) err = %v, want ErrNegative", name, err)
+		}
+	}
+}
+
+func TestBig(t *testing.T) {
+	for n := 0; n <= 92; n++ {
+		want, _ := fibonacci.Iterative(n)
+		got, err := fibonacci.Big(n)
+		if err != nil || !got.IsInt64() || got.Int64() != int64(want) {
+			t.Fatalf("Big(%d) = %v, %v, want %d", n, got, err, want)
+		}
+	}
+
+	got, _ := fibonacci.Big(100)
+	if want := "354224848179261915075"; got.String() != want {
+		t.Errorf("Big(100) = %v, want %s", got, want)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := fibonacci.Generate(ctx)
+	for _, want := range first {
+		if got := <-ch; got.Int64() != int64(want) {
+			t.Fatalf("Generate = %v, want %d", got, want)
+		}
+	}
+	cancel()
+
+	// 取消后 channel 会被关闭，最多还能收到一个已经准备好的值
+	for range ch {
+	}
+}
+
+func ExampleGenerate() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for f := range fibonacci.Generate(ctx) {
+		if f.BitLen() > 8 {
+			break
+		}
+		fmt.Print(f, " ")
+	}
+	// Output: 0 1 1 2 3 5 8 13 21 34 55 89 144 233
+}
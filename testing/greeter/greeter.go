@@ -0,0 +1,171 @@
+package greeter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultLanguage 找不到对应语言时使用的语言
+const DefaultLanguage = "en"
+
+// catalog 内置的问候语，模板的数据为 Message
+var catalog = map[string]string{
+	"en": "Hello, {{.Name}}",
+	"zh": "你好，{{.Name}}",
+	"es": "¡Hola, {{.Name}}!",
+	"fr": "Bonjour, {{.Name}}",
+}
+
+// Message 渲染问候语模板时使用的数据
+type Message struct {
+	Name     string
+	Language string
+}
+
+// Greeter 根据语言选择问候语模板，可以被多个 goroutine 同时使用
+type Greeter struct {
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// New 返回一个加载了内置 en、zh、es、fr 问候语的 Greeter
+func New() *Greeter {
+	g := &Greeter{templates: make(map[string]*template.Template)}
+	for lang, text := range catalog {
+		g.templates[lang] = template.Must(template.New(lang).Parse(text))
+	}
+	return g
+}
+
+// SetTemplate 使用 text/template 语法添加或覆盖某个语言的问候语
+func (g *Greeter) SetTemplate(lang, text string) error {
+	lang = normalize(lang)
+	tmpl, err := template.New(lang).Parse(text)
+	if err != nil {
+		return fmt.Errorf("greeter: parse template for %q: %w", lang, err)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.templates[lang] = tmpl
+	return nil
+}
+
+// Languages 返回已经支持的语言，按字母排序
+func (g *Greeter) Languages() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	langs := make([]string, 0, len(g.templates))
+	for lang := range g.templates {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Greet 把 name 的问候语写入 w
+//
+// lang 是 BCP 47 语言标签，例如 "zh-CN"，只使用主语言部分进行匹配，
+// 不支持的语言会回退到 DefaultLanguage
+func (g *Greeter) Greet(w io.Writer, lang, name string) error {
+	tmpl, lang := g.lookup(lang)
+	return tmpl.Execute(w, Message{Name: name, Language: lang})
+}
+
+// lookup 返回语言对应的模板以及实际使用的语言
+func (g *Greeter) lookup(lang string) (*template.Template, string) {
+	lang = normalize(lang)
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if tmpl, ok := g.templates[lang]; ok {
+		return tmpl, lang
+	}
+	return g.templates[DefaultLanguage], DefaultLanguage
+}
+
+func (g *Greeter) supports(lang string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.templates[normalize(lang)]
+	return ok
+}
+
+// ServeHTTP 根据 Accept-Language 请求头选择语言，问候查询参数 name 指定的人
+//
+// 模板先渲染到缓冲区，执行失败时只返回 500，不会在已经发送的部分内容之后追加错误信息
+func (g *Greeter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "World"
+	}
+
+	lang := DefaultLanguage
+	for _, tag := range ParseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if g.supports(tag) {
+			lang = normalize(tag)
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.Greet(&buf, lang, name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	_, _ = buf.WriteTo(w)
+}
+
+// ParseAcceptLanguage 解析 Accept-Language 请求头，按权重 q 从高到低返回语言标签
+//
+// 例如 "fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5" 返回 [fr-CH fr en *]，q=0 的语言会被忽略
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag, q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// normalize 取语言标签的主语言部分并转为小写，"zh-Hans-CN" 和 "zh_CN" 都返回 "zh"
+func normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
@@ -0,0 +1,117 @@
+package greeter_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"standard-library-examples/testing/greeter"
+)
+
+func TestGreet(t *testing.T) {
+	g := greeter.New()
+	tests := []struct {
+		lang, want string
+	}{
+		{"en", "Hello, Chris"},
+		{"zh-CN", "你好，Chris"},
+		{"es_MX", "¡Hola, Chris!"},
+		{"FR", "Bonjour, Chris"},
+		{"de", "Hello, Chris"},
+		{"", "Hello, Chris"},
+	}
+
+	for _, tt := range tests {
+		buffer := bytes.Buffer{}
+		if err := g.Greet(&buffer, tt.lang, "Chris"); err != nil {
+			t.Fatalf("Greet(%q) err: %v", tt.lang, err)
+		}
+		if got := buffer.String(); got != tt.want {
+			t.Errorf("Greet(%q) = %q want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestSetTemplate(t *testing.T) {
+	g := greeter.New()
+	if err := g.SetTemplate("de", "Hallo, {{.Name}} ({{.Language}})"); err != nil {
+		t.Fatalf("SetTemplate err: %v", err)
+	}
+	if err := g.SetTemplate("en", "Hi {{.Name}}!"); err != nil {
+		t.Fatalf("SetTemplate err: %v", err)
+	}
+	if err := g.SetTemplate("it", "Ciao, {{.Name"); err == nil {
+		t.Errorf("SetTemplate with invalid template should fail")
+	}
+
+	buffer := bytes.Buffer{}
+	_ = g.Greet(&buffer, "de-AT", "Chris")
+	_ = g.Greet(&buffer, "it", "Chris")
+	if got, want := buffer.String(), "Hallo, Chris (de)Hi Chris!"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got, want := g.Languages(), []string{"de", "en", "es", "fr", "zh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Languages() = %v want %v", got, want)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	got := greeter.ParseAcceptLanguage("en;q=0.8, fr-CH, *;q=0.5, fr;q=0.9, de;q=0")
+	want := []string{"fr-CH", "fr", "en", "*"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	server := httptest.NewServer(greeter.New())
+	defer server.Close()
+
+	tests := []struct {
+		acceptLanguage, want, lang string
+	}{
+		{"de-DE, zh-CN;q=0.9, en;q=0.8", "你好，Chris", "zh"},
+		{"ja", "Hello, Chris", "en"},
+		{"", "Hello, Chris", "en"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"?name=Chris", nil)
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("http.Do err: %v", err)
+		}
+		buffer := bytes.Buffer{}
+		_, _ = buffer.ReadFrom(resp.Body)
+		_ = resp.Body.Close()
+
+		if got := buffer.String(); got != tt.want {
+			t.Errorf("Accept-Language %q: got %q want %q", tt.acceptLanguage, got, tt.want)
+		}
+		if got := resp.Header.Get("Content-Language"); got != tt.lang {
+			t.Errorf("Content-Language = %q want %q", got, tt.lang)
+		}
+	}
+}
+
+// TestServeHTTPTemplateError 模板执行到一半失败时，响应中不能包含已经渲染的部分内容
+func TestServeHTTPTemplateError(t *testing.T) {
+	g := greeter.New()
+	// 调用 string 没有的方法，在输出 "Hello, Chris" 之后才会失败
+	if err := g.SetTemplate("en", "Hello, {{.Name}}{{.Name.Missing}}"); err != nil {
+		t.Fatalf("SetTemplate err: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?name=Chris", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "Hello, Chris") {
+		t.Errorf("body contains partial greeting: %q", body)
+	}
+}
@@ -1,55 +1,78 @@
 package testing_test
 
 import (
-	"fmt"
+	"io"
+	"strings"
 	"testing"
+
+	"standard-library-examples/testing/numeric"
+	"standard-library-examples/testing/repeat"
 )
 
 func TestAdder(t *testing.T) {
-	sum := Add(2, 2)
+	sum, err := numeric.Add(2, 2)
 	expected := 4
 
+	if err != nil {
+		t.Fatalf("numeric.Add err: %v", err)
+	}
 	if sum != expected {
 		t.Errorf("Expected '%d', but got '%d'", expected, sum)
 	}
 }
 
-func Add(m, n int) int {
-	return m + n
-}
-
-func ExampleAdd() {
-	sum := Add(1, 5)
-	fmt.Println(sum)
-	// Output: 6
-}
-
 const repeatCount = 5
 
-func Repeat(str string) string {
+// concatRepeat 通过字符串拼接重复 str，每次循环都会分配一个新字符串
+func concatRepeat(str string, count int) string {
 	var repeated string
-	for i := 0; i < repeatCount; i++ {
+	for i := 0; i < count; i++ {
 		repeated += str
 	}
 	return repeated
 }
 
 func TestRepeat(t *testing.T) {
-	repeated := Repeat("a")
+	repeated, err := repeat.Repeat("a", repeatCount, "")
 	expected := "aaaaa"
 
+	if err != nil {
+		t.Fatalf("repeat.Repeat err: %v", err)
+	}
 	if repeated != expected {
 		t.Errorf("Expected '%s', but got '%s'", expected, repeated)
 	}
 }
 
+// go test ./testing -bench=BenchmarkRepeat -benchmem
+//
+// 拼接每次循环都要复制整个字符串，strings.Repeat 通过倍增复制只需要 O(log n) 次 copy，
+// repeat.Repeat 逐段写入但只分配一次内存，WriteRepeat 经过缓冲直接写入 io.Writer，不保存结果
 func BenchmarkRepeat(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		Repeat("a")
-	}
-}
+	const count = 1000
 
-func ExampleRepeat() {
-	fmt.Println(Repeat("a"))
-	// Output: aaaaa
+	b.Run("concat", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			concatRepeat("a", count)
+		}
+	})
+	b.Run("strings.Repeat", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			strings.Repeat("a", count)
+		}
+	})
+	b.Run("repeat.Repeat", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = repeat.Repeat("a", count, "")
+		}
+	})
+	b.Run("WriteRepeat", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = repeat.WriteRepeat(io.Discard, "a", count, "")
+		}
+	})
 }
@@ -0,0 +1,154 @@
+package numeric
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrOverflow 表示运算结果超出了类型的表示范围
+var ErrOverflow = errors.New("numeric: overflow")
+
+// ErrEmpty 表示对空切片求平均值
+var ErrEmpty = errors.New("numeric: empty input")
+
+// Signed 有符号整数
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned 无符号整数
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Integer 所有整数类型
+type Integer interface {
+	Signed | Unsigned
+}
+
+// Float 浮点数类型
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number 支持的所有数值类型
+type Number interface {
+	Integer | Float
+}
+
+// isFloat 判断 T 是否为浮点类型：0.5 转换为整数时会被截断为 0
+func isFloat[T Number]() bool {
+	half := 0.5
+	return T(half) != 0
+}
+
+// isSigned 判断 T 是否可以表示负数，无符号整数的 0-1 会回绕为最大值
+func isSigned[T Number]() bool {
+	var zero, one T = 0, 1
+	return zero-one < 0
+}
+
+// isInf 判断浮点数是否溢出为无穷大
+func isInf[T Number](v T) bool {
+	return math.IsInf(float64(v), 0)
+}
+
+// Add 返回 a + b，结果溢出时返回 ErrOverflow
+func Add[T Number](a, b T) (T, error) {
+	c := a + b
+	switch {
+	case isFloat[T]():
+		if isInf(c) && !isInf(a) && !isInf(b) {
+			return c, fmt.Errorf("%w: %v + %v", ErrOverflow, a, b)
+		}
+	case isSigned[T]():
+		// 两个同号数相加，结果的符号发生变化即为溢出
+		if (b > 0 && c < a) || (b < 0 && c > a) {
+			return c, fmt.Errorf("%w: %v + %v", ErrOverflow, a, b)
+		}
+	default:
+		if c < a {
+			return c, fmt.Errorf("%w: %v + %v", ErrOverflow, a, b)
+		}
+	}
+	return c, nil
+}
+
+// Mul 返回 a * b，结果溢出时返回 ErrOverflow
+func Mul[T Number](a, b T) (T, error) {
+	c := a * b
+	if isFloat[T]() {
+		if isInf(c) && !isInf(a) && !isInf(b) {
+			return c, fmt.Errorf("%w: %v * %v", ErrOverflow, a, b)
+		}
+		return c, nil
+	}
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	if isSigned[T]() {
+		// 最小值乘以 -1 时，c/a 同样会回绕，需要单独判断：只有 0 和最小值满足 v == -v
+		var minusOne T = 0
+		minusOne--
+		if (a == minusOne && b == -b) || (b == minusOne && a == -a) {
+			return c, fmt.Errorf("%w: %v * %v", ErrOverflow, a, b)
+		}
+	}
+	if c/b != a {
+		return c, fmt.Errorf("%w: %v * %v", ErrOverflow, a, b)
+	}
+	return c, nil
+}
+
+// Sum 计算切片中所有元素的和
+func Sum[T Number](numbers []T) (T, error) {
+	var sum T
+	for _, number := range numbers {
+		var err error
+		if sum, err = Add(sum, number); err != nil {
+			return sum, err
+		}
+	}
+	return sum, nil
+}
+
+// SumAll 分别计算每个切片的和
+func SumAll[T Number](numbersToSum ...[]T) ([]T, error) {
+	sums := make([]T, 0, len(numbersToSum))
+	for _, numbers := range numbersToSum {
+		sum, err := Sum(numbers)
+		if err != nil {
+			return nil, err
+		}
+		sums = append(sums, sum)
+	}
+	return sums, nil
+}
+
+// SumAllTails 分别计算每个切片除第一个元素外的和，空切片的和为 0
+func SumAllTails[T Number](numbersToSum ...[]T) ([]T, error) {
+	tails := make([][]T, 0, len(numbersToSum))
+	for _, numbers := range numbersToSum {
+		if len(numbers) == 0 {
+			tails = append(tails, nil)
+			continue
+		}
+		tails = append(tails, numbers[1:])
+	}
+	return SumAll(tails...)
+}
+
+// Mean 计算算术平均值
+//
+// 使用增量公式 mean += (x - mean) / n，不需要先求和，因此不会因为和溢出而失败
+func Mean[T Number](numbers []T) (float64, error) {
+	if len(numbers) == 0 {
+		return 0, ErrEmpty
+	}
+	var mean float64
+	for i, number := range numbers {
+		mean += (float64(number) - mean) / float64(i+1)
+	}
+	return mean, nil
+}
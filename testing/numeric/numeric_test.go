@@ -0,0 +1,308 @@
+package numeric_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"standard-library-examples/testing/numeric"
+)
+
+func TestAdd(t *testing.T) {
+	if got, err := numeric.Add(2, 2); err != nil || got != 4 {
+		t.Errorf("Add(2, 2) = %d, %v, want 4, nil", got, err)
+	}
+	if _, err := numeric.Add[int8](math.MaxInt8, 1); !errors.Is(err, numeric.ErrOverflow) {
+		t.Errorf("Add(MaxInt8, 1) err = %v, want ErrOverflow", err)
+	}
+	if _, err := numeric.Add[uint8](0, math.MaxUint8); err != nil {
+		t.Errorf("Add(0, MaxUint8) err = %v, want nil", err)
+	}
+	if _, err := numeric.Add(math.MaxFloat64, math.MaxFloat64); !errors.Is(err, numeric.ErrOverflow) {
+		t.Errorf("Add(MaxFloat64, MaxFloat64) err = %v, want ErrOverflow", err)
+	}
+}
+
+func TestMul(t *testing.T) {
+	if _, err := numeric.Mul[int8](math.MinInt8, -1); !errors.Is(err, numeric.ErrOverflow) {
+		t.Errorf("Mul(MinInt8, -1) err = %v, want ErrOverflow", err)
+	}
+	if got, err := numeric.Mul[int8](-1, math.MaxInt8); err != nil || got != -math.MaxInt8 {
+		t.Errorf("Mul(-1, MaxInt8) = %d, %v", got, err)
+	}
+}
+
+func TestSumAllTails(t *testing.T) {
+	got, err := numeric.SumAllTails([]int{}, []int{3, 4, 5}, nil)
+	if err != nil {
+		t.Fatalf("SumAllTails err: %v", err)
+	}
+	if want := []int{0, 9, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if _, err := numeric.SumAll([]uint8{200, 100}); !errors.Is(err, numeric.ErrOverflow) {
+		t.Errorf("SumAll err = %v, want ErrOverflow", err)
+	}
+}
+
+func TestMean(t *testing.T) {
+	mean, err := numeric.Mean([]int64{math.MaxInt64, math.MaxInt64})
+	if err != nil {
+		t.Fatalf("Mean err: %v", err)
+	}
+	if mean != math.MaxInt64 {
+		t.Errorf("Mean = %v, want %v", mean, float64(math.MaxInt64))
+	}
+	if _, err := numeric.Mean([]int{}); !errors.Is(err, numeric.ErrEmpty) {
+		t.Errorf("Mean(empty) err = %v, want ErrEmpty", err)
+	}
+}
+
+// FuzzAddInt64 与 math/big 的计算结果比较
+func FuzzAddInt64(f *testing.F) {
+	f.Add(int64(1), int64(2))
+	f.Add(int64(math.MaxInt64), int64(1))
+	f.Add(int64(math.MinInt64), int64(-1))
+	f.Fuzz(func(t *testing.T, a, b int64) {
+		want := new(big.Int).Add(big.NewInt(a), big.NewInt(b))
+		got, err := numeric.Add(a, b)
+		checkInt64(t, "Add", a, b, want, got, err)
+	})
+}
+
+func FuzzMulInt64(f *testing.F) {
+	f.Add(int64(3), int64(-7))
+	f.Add(int64(math.MinInt64), int64(-1))
+	f.Add(int64(1<<32), int64(1<<31))
+	f.Fuzz(func(t *testing.T, a, b int64) {
+		want := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+		got, err := numeric.Mul(a, b)
+		checkInt64(t, "Mul", a, b, want, got, err)
+	})
+}
+
+func FuzzMulUint64(f *testing.F) {
+	f.Add(uint64(3), uint64(7))
+	f.Add(uint64(math.MaxUint64), uint64(2))
+	f.Fuzz(func(t *testing.T, a, b uint64) {
+		want := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+		got, err := numeric.Mul(a, b)
+		if !want.IsUint64() {
+			if !errors.Is(err, numeric.ErrOverflow) {
+				t.Fatalf("Mul(%d, %d) err = %v, want ErrOverflow", a, b, err)
+			}
+			return
+		}
+		if err != nil || got != want.Uint64() {
+			t.Fatalf("Mul(%d, %d) = %d, %v, want %s", a, b, got, err, want)
+		}
+	})
+}
+
+// FuzzSumInt8 把字节串当作 []int8 求和，int8 的范围很小，更容易触发溢出
+func FuzzSumInt8(f *testing.F) {
+	f.Add([]byte{1, 2, 3})
+	f.Add([]byte{0x7f, 0x01})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		numbers := make([]int8, len(data))
+		want := new(big.Int)
+		overflow := false
+		for i, b := range data {
+			numbers[i] = int8(b)
+			want.Add(want, big.NewInt(int64(numbers[i])))
+			if want.Cmp(big.NewInt(math.MaxInt8)) > 0 || want.Cmp(big.NewInt(math.MinInt8)) < 0 {
+				overflow = true
+			}
+		}
+		got, err := numeric.Sum(numbers)
+		if overflow {
+			if !errors.Is(err, numeric.ErrOverflow) {
+				t.Fatalf("Sum(%v) err = %v, want ErrOverflow", numbers, err)
+			}
+			return
+		}
+		if err != nil || int64(got) != want.Int64() {
+			t.Fatalf("Sum(%v) = %d, %v, want %s", numbers, got, err, want)
+		}
+	})
+}
+
+func FuzzAddUint64(f *testing.F) {
+	f.Add(uint64(1), uint64(2))
+	f.Add(uint64(math.MaxUint64), uint64(1))
+	f.Fuzz(func(t *testing.T, a, b uint64) {
+		want := new(big.Int).Add(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+		got, err := numeric.Add(a, b)
+		if !want.IsUint64() {
+			if !errors.Is(err, numeric.ErrOverflow) {
+				t.Fatalf("Add(%d, %d) err = %v, want ErrOverflow", a, b, err)
+			}
+			return
+		}
+		if err != nil || got != want.Uint64() {
+			t.Fatalf("Add(%d, %d) = %d, %v, want %s", a, b, got, err, want)
+		}
+	})
+}
+
+// FuzzAddFloat64 big.Float 的指数范围不受限制，转换回 float64 时得到 ±Inf 即为溢出
+func FuzzAddFloat64(f *testing.F) {
+	f.Add(1.5, 2.25)
+	f.Add(math.MaxFloat64, math.MaxFloat64)
+	f.Add(-math.MaxFloat64, -math.MaxFloat64/2)
+	f.Fuzz(func(t *testing.T, a, b float64) {
+		if !isFinite(a) || !isFinite(b) {
+			t.Skip()
+		}
+		want := newFloat(a).Add(newFloat(a), newFloat(b))
+		got, err := numeric.Add(a, b)
+		checkFloat64(t, "Add", a, b, want, got, err)
+	})
+}
+
+func FuzzMulFloat64(f *testing.F) {
+	f.Add(1.5, -4.0)
+	f.Add(math.MaxFloat64, 2.0)
+	f.Add(1e200, -1e200)
+	f.Fuzz(func(t *testing.T, a, b float64) {
+		if !isFinite(a) || !isFinite(b) {
+			t.Skip()
+		}
+		want := newFloat(a).Mul(newFloat(a), newFloat(b))
+		got, err := numeric.Mul(a, b)
+		checkFloat64(t, "Mul", a, b, want, got, err)
+	})
+}
+
+// FuzzSumAllTails 以 0 为分隔符把字节串切成多个 []int8
+func FuzzSumAllTails(f *testing.F) {
+	f.Add([]byte{1, 2, 0, 3, 4, 5})
+	f.Add([]byte{0x7f, 0x7f, 0, 0x01, 0x7f, 0x7f})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var groups, tails [][]int8
+		for _, part := range bytes.Split(data, []byte{0}) {
+			numbers := make([]int8, len(part))
+			for i, b := range part {
+				numbers[i] = int8(b)
+			}
+			groups = append(groups, numbers)
+			if len(numbers) > 0 {
+				numbers = numbers[1:]
+			}
+			tails = append(tails, numbers)
+		}
+
+		got, err := numeric.SumAll(groups...)
+		checkSums(t, "SumAll", groups, got, err)
+		got, err = numeric.SumAllTails(groups...)
+		checkSums(t, "SumAllTails", tails, got, err)
+	})
+}
+
+// FuzzMean 每 8 个字节解码为一个 int64，与 big.Rat 计算的精确平均值比较
+func FuzzMean(f *testing.F) {
+	f.Add([]byte{1, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0})
+	f.Add(bytes.Repeat([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 4))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		numbers := make([]int64, len(data)/8)
+		sum := new(big.Rat)
+		maxAbs := 1.0
+		for i := range numbers {
+			numbers[i] = int64(binary.LittleEndian.Uint64(data[i*8:]))
+			sum.Add(sum, new(big.Rat).SetInt64(numbers[i]))
+			maxAbs = math.Max(maxAbs, math.Abs(float64(numbers[i])))
+		}
+
+		got, err := numeric.Mean(numbers)
+		if len(numbers) == 0 {
+			if !errors.Is(err, numeric.ErrEmpty) {
+				t.Fatalf("Mean(empty) err = %v, want ErrEmpty", err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("Mean(%v) err: %v", numbers, err)
+		}
+		want, _ := sum.Quo(sum, new(big.Rat).SetInt64(int64(len(numbers)))).Float64()
+		if tolerance := 1e-12 * maxAbs * float64(len(numbers)); math.Abs(got-want) > tolerance {
+			t.Fatalf("Mean(%v) = %v, want %v (tolerance %v)", numbers, got, want, tolerance)
+		}
+	})
+}
+
+func checkInt64(t *testing.T, op string, a, b int64, want *big.Int, got int64, err error) {
+	t.Helper()
+	if !want.IsInt64() {
+		if !errors.Is(err, numeric.ErrOverflow) {
+			t.Fatalf("%s(%d, %d) err = %v, want ErrOverflow", op, a, b, err)
+		}
+		return
+	}
+	if err != nil || got != want.Int64() {
+		t.Fatalf("%s(%d, %d) = %d, %v, want %s", op, a, b, got, err, want)
+	}
+}
+
+func ExampleAdd() {
+	sum, _ := numeric.Add(1, 5)
+	fmt.Println(sum)
+
+	_, err := numeric.Add[int8](127, 1)
+	fmt.Println(err)
+	// Output:
+	// 6
+	// numeric: overflow: 127 + 1
+}
+
+func newFloat(v float64) *big.Float {
+	return new(big.Float).SetPrec(53).SetFloat64(v)
+}
+
+func isFinite(v float64) bool {
+	return !math.IsInf(v, 0) && !math.IsNaN(v)
+}
+
+func checkFloat64(t *testing.T, op string, a, b float64, want *big.Float, got float64, err error) {
+	t.Helper()
+	w, _ := want.Float64()
+	if math.IsInf(w, 0) {
+		if !errors.Is(err, numeric.ErrOverflow) {
+			t.Fatalf("%s(%v, %v) err = %v, want ErrOverflow", op, a, b, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("%s(%v, %v) err: %v", op, a, b, err)
+	}
+	// 非规格化数在 big.Float 中会经历两次舍入，只比较规格化范围内的结果
+	if math.Abs(w) >= 0x1p-1022 && got != w {
+		t.Fatalf("%s(%v, %v) = %v, want %v", op, a, b, got, w)
+	}
+}
+
+func checkSums(t *testing.T, op string, groups [][]int8, got []int8, err error) {
+	t.Helper()
+	want := make([]int8, 0, len(groups))
+	for _, numbers := range groups {
+		sum := new(big.Int)
+		for _, number := range numbers {
+			sum.Add(sum, big.NewInt(int64(number)))
+			if !sum.IsInt64() || sum.Int64() > math.MaxInt8 || sum.Int64() < math.MinInt8 {
+				if !errors.Is(err, numeric.ErrOverflow) {
+					t.Fatalf("%s(%v) err = %v, want ErrOverflow", op, groups, err)
+				}
+				return
+			}
+		}
+		want = append(want, int8(sum.Int64()))
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s(%v) = %v, %v, want %v", op, groups, got, err, want)
+	}
+}
@@ -0,0 +1,103 @@
+package repeat
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+
+	"standard-library-examples/testing/numeric"
+)
+
+// MaxLen Repeat 返回的字符串的最大字节数，更大的结果应当使用 WriteRepeat 写入 io.Writer
+const MaxLen = 1 << 30
+
+var (
+	// ErrNegativeCount 重复次数为负数
+	ErrNegativeCount = errors.New("repeat: negative count")
+	// ErrTooLarge 结果长度超过 MaxLen 或者超出 int64 的范围
+	ErrTooLarge = errors.New("repeat: result too large")
+)
+
+// Size 返回把 s 重复 count 次并以 sep 分隔后的总字节数
+func Size(s string, count int, sep string) (int64, error) {
+	if count < 0 {
+		return 0, ErrNegativeCount
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	body, err := numeric.Mul(int64(len(s)), int64(count))
+	if err != nil {
+		return 0, ErrTooLarge
+	}
+	seps, err := numeric.Mul(int64(len(sep)), int64(count-1))
+	if err != nil {
+		return 0, ErrTooLarge
+	}
+	size, err := numeric.Add(body, seps)
+	if err != nil {
+		return 0, ErrTooLarge
+	}
+	return size, nil
+}
+
+// Repeat 把 s 重复 count 次，相邻两次之间插入 sep
+//
+// 结果通过 strings.Builder 一次性分配好内存，避免字符串拼接带来的多次分配
+func Repeat(s string, count int, sep string) (string, error) {
+	size, err := Size(s, count, sep)
+	if err != nil {
+		return "", err
+	}
+	if size > MaxLen {
+		return "", ErrTooLarge
+	}
+
+	var builder strings.Builder
+	builder.Grow(int(size))
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			builder.WriteString(sep)
+		}
+		builder.WriteString(s)
+	}
+	return builder.String(), nil
+}
+
+// WriteRepeat 把 s 重复 count 次写入 w，相邻两次之间插入 sep，返回写入的字节数
+//
+// 结果不会保存在内存中，适合生成非常大的输出。
+// 写入经过 bufio.Writer 缓冲，s 和 sep 很短时也不会每次都调用 w.Write；
+// 出错时返回的字节数只包含已经写入 w 的部分。
+func WriteRepeat(w io.Writer, s string, count int, sep string) (int64, error) {
+	if _, err := Size(s, count, sep); err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+	written, err := writeRepeat(bw, s, count, sep)
+	if err == nil {
+		err = bw.Flush()
+	}
+	return written - int64(bw.Buffered()), err
+}
+
+func writeRepeat(w *bufio.Writer, s string, count int, sep string) (int64, error) {
+	var written int64
+	for i := 0; i < count; i++ {
+		if i > 0 && sep != "" {
+			n, err := w.WriteString(sep)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		}
+		n, err := w.WriteString(s)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
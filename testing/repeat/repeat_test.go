@@ -0,0 +1,101 @@
+package repeat_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	"standard-library-examples/testing/repeat"
+)
+
+func TestRepeat(t *testing.T) {
+	tests := []struct {
+		s     string
+		count int
+		sep   string
+		want  string
+	}{
+		{"a", 5, "", "aaaaa"},
+		{"ab", 3, ", ", "ab, ab, ab"},
+		{"a", 1, "-", "a"},
+		{"a", 0, "-", ""},
+		{"", 3, "-", "--"},
+	}
+
+	for _, tt := range tests {
+		got, err := repeat.Repeat(tt.s, tt.count, tt.sep)
+		if err != nil {
+			t.Fatalf("Repeat(%q, %d, %q) err: %v", tt.s, tt.count, tt.sep, err)
+		}
+		if got != tt.want {
+			t.Errorf("Repeat(%q, %d, %q) = %q, want %q", tt.s, tt.count, tt.sep, got, tt.want)
+		}
+	}
+}
+
+func TestRepeatErrors(t *testing.T) {
+	if _, err := repeat.Repeat("a", -1, ""); !errors.Is(err, repeat.ErrNegativeCount) {
+		t.Errorf("negative count err = %v, want ErrNegativeCount", err)
+	}
+	if _, err := repeat.Repeat("ab", repeat.MaxLen, ""); !errors.Is(err, repeat.ErrTooLarge) {
+		t.Errorf("oversized err = %v, want ErrTooLarge", err)
+	}
+	if _, err := repeat.WriteRepeat(io.Discard, "abc", math.MaxInt, ""); !errors.Is(err, repeat.ErrTooLarge) {
+		t.Errorf("int64 overflow err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestWriteRepeat(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := repeat.WriteRepeat(&buf, "go", 3, "|")
+	if err != nil {
+		t.Fatalf("WriteRepeat err: %v", err)
+	}
+	if got, want := buf.String(), "go|go|go"; got != want || n != int64(len(want)) {
+		t.Errorf("WriteRepeat = %q (%d bytes), want %q", got, n, want)
+	}
+
+	// 输出比 bufio.Writer 的缓冲区大时分多次写入
+	n, err = repeat.WriteRepeat(io.Discard, "0123456789abcdef", 10000, ",")
+	if want := int64(16*10000 + 9999); err != nil || n != want {
+		t.Errorf("WriteRepeat(io.Discard) = %d, %v, want %d", n, err, want)
+	}
+
+	// 出错时返回的字节数是 w 实际接收的字节数，不包含缓冲区中的数据
+	w := &limitWriter{limit: 5000}
+	n, err = repeat.WriteRepeat(w, "0123456789abcdef", 10000, "")
+	if !errors.Is(err, errFull) || n != int64(w.n) {
+		t.Errorf("WriteRepeat(limitWriter) = %d, %v, writer got %d bytes", n, err, w.n)
+	}
+}
+
+var errFull = errors.New("writer full")
+
+// limitWriter 最多接收 limit 个字节
+type limitWriter struct {
+	n, limit int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if w.n+len(p) > w.limit {
+		p = p[:w.limit-w.n]
+		w.n += len(p)
+		return len(p), errFull
+	}
+	w.n += len(p)
+	return len(p), nil
+}
+
+func ExampleRepeat() {
+	s, _ := repeat.Repeat("a", 5, "")
+	fmt.Println(s)
+
+	s, _ = repeat.Repeat("go", 3, ", ")
+	fmt.Println(s)
+	// Output:
+	// aaaaa
+	// go, go, go
+}
@@ -0,0 +1,132 @@
+// structlayout 打印 Go 源代码中结构体的内存布局，以及字段重新排序后的布局
+//
+// 用法：
+//
+//	go run ./unsafe/layout/cmd/structlayout -dir ./some/pkg User Order
+//	go run ./unsafe/layout/cmd/structlayout -arch 386 -dir ./some/pkg User
+//
+// 不指定类型名时打印包中所有的结构体
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"standard-library-examples/unsafe/layout"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the Go package to inspect")
+	arch := flag.String("arch", runtime.GOARCH, "target GOARCH used to compute sizes")
+	optimize := flag.Bool("optimize", true, "also print the suggested field order")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("structlayout: ")
+
+	pkg, err := load(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sizes := types.SizesFor("gc", *arch)
+	if sizes == nil {
+		log.Fatalf("unknown arch %q", *arch)
+	}
+
+	names := flag.Args()
+	if len(names) == 0 {
+		names = structNames(pkg)
+	}
+	for _, name := range names {
+		l, err := inspect(pkg, sizes, name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_ = l.WriteTable(os.Stdout)
+		if !*optimize {
+			fmt.Println()
+			continue
+		}
+		if opt := l.Optimize(); opt.Size < l.Size {
+			fmt.Printf("\nsuggested order saves %d bytes:\n", l.Size-opt.Size)
+			_ = opt.WriteTable(os.Stdout)
+		}
+		fmt.Println()
+	}
+}
+
+// load 解析并检查 dir 中的 Go 包，忽略测试文件
+func load(dir string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	return conf.Check(dir, fset, files, nil)
+}
+
+// structNames 返回包中所有结构体类型的名字
+func structNames(pkg *types.Package) []string {
+	var names []string
+	for _, name := range pkg.Scope().Names() {
+		if tn, ok := pkg.Scope().Lookup(name).(*types.TypeName); ok {
+			if _, ok := tn.Type().Underlying().(*types.Struct); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inspect 使用 go/types 计算结构体的布局
+func inspect(pkg *types.Package, sizes types.Sizes, name string) (*layout.Layout, error) {
+	obj := pkg.Scope().Lookup(name)
+	if obj == nil {
+		return nil, fmt.Errorf("type %s not found in %s", name, pkg.Path())
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", name)
+	}
+
+	vars := make([]*types.Var, st.NumFields())
+	for i := range vars {
+		vars[i] = st.Field(i)
+	}
+	offsets := sizes.Offsetsof(vars)
+	fields := make([]layout.Field, len(vars))
+	for i, v := range vars {
+		fields[i] = layout.Field{
+			Name:   v.Name(),
+			Type:   types.TypeString(v.Type(), types.RelativeTo(pkg)),
+			Offset: uintptr(offsets[i]),
+			Size:   uintptr(sizes.Sizeof(v.Type())),
+			Align:  uintptr(sizes.Alignof(v.Type())),
+		}
+	}
+	return layout.New(name, uintptr(sizes.Sizeof(st)), uintptr(sizes.Alignof(st)), fields), nil
+}
@@ -0,0 +1,158 @@
+// Package layout 报告结构体字段在内存中的布局
+//
+// 字段的偏移、大小和对齐与 unsafe.Offsetof、unsafe.Sizeof、unsafe.Alignof 的结果一致，
+// 并给出编译器为了对齐插入的填充字节。
+package layout
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+)
+
+// Field 一个字段，或者字段之间的填充
+type Field struct {
+	Name   string
+	Type   string
+	Offset uintptr
+	Size   uintptr
+	Align  uintptr
+}
+
+// Padding 两个字段之间或者结构体末尾的填充字节
+type Padding struct {
+	After  string // 填充之前的字段，结构体开头不会有填充
+	Offset uintptr
+	Size   uintptr
+}
+
+// Layout 结构体的内存布局
+type Layout struct {
+	Name    string
+	Size    uintptr
+	Align   uintptr
+	Fields  []Field
+	Padding []Padding
+}
+
+// Of 返回结构体类型 t 的布局，t 也可以是指向结构体的指针
+func Of(t reflect.Type) (*Layout, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("layout: %v is not a struct", t)
+	}
+
+	fields := make([]Field, t.NumField())
+	for i := range fields {
+		f := t.Field(i)
+		fields[i] = Field{
+			Name:   f.Name,
+			Type:   f.Type.String(),
+			Offset: f.Offset,
+			Size:   f.Type.Size(),
+			Align:  uintptr(f.Type.Align()),
+		}
+	}
+	return New(t.String(), t.Size(), uintptr(t.Align()), fields), nil
+}
+
+// OfValue 返回 v 的类型的布局，例如 OfValue(User{})
+func OfValue(v any) (*Layout, error) {
+	if v == nil {
+		return nil, fmt.Errorf("layout: nil value")
+	}
+	return Of(reflect.TypeOf(v))
+}
+
+// New 根据已经计算好的字段信息构造 Layout，并计算其中的填充
+//
+// 字段的类型信息不是来自 reflect 时使用，例如通过 go/types 分析源代码
+func New(name string, size, align uintptr, fields []Field) *Layout {
+	l := &Layout{Name: name, Size: size, Align: align, Fields: fields}
+	var end uintptr
+	after := ""
+	for _, f := range fields {
+		if f.Offset > end {
+			l.Padding = append(l.Padding, Padding{After: after, Offset: end, Size: f.Offset - end})
+		}
+		end = f.Offset + f.Size
+		after = f.Name
+	}
+	if size > end {
+		l.Padding = append(l.Padding, Padding{After: after, Offset: end, Size: size - end})
+	}
+	return l
+}
+
+// PaddingSize 返回填充字节的总数
+func (l *Layout) PaddingSize() uintptr {
+	var n uintptr
+	for _, p := range l.Padding {
+		n += p.Size
+	}
+	return n
+}
+
+// Optimize 返回字段重新排序后的布局，使结构体尽可能小
+//
+// 按对齐从大到小排序即可消除字段之间的填充；对齐相同时保持原来的顺序。
+// 大小为 0 的字段放在开头，因为位于末尾的零大小字段会让编译器额外填充，
+// 避免取地址时指向结构体之后的内存。
+func (l *Layout) Optimize() *Layout {
+	fields := make([]Field, len(l.Fields))
+	copy(fields, l.Fields)
+	sort.SliceStable(fields, func(i, j int) bool {
+		if (fields[i].Size == 0) != (fields[j].Size == 0) {
+			return fields[i].Size == 0
+		}
+		return fields[i].Align > fields[j].Align
+	})
+
+	var offset, align uintptr = 0, 1
+	for i := range fields {
+		offset = alignUp(offset, fields[i].Align)
+		fields[i].Offset = offset
+		offset += fields[i].Size
+		if fields[i].Align > align {
+			align = fields[i].Align
+		}
+	}
+	if l.Align > align {
+		align = l.Align
+	}
+	return New(l.Name, alignUp(offset, align), align, fields)
+}
+
+// alignUp 把 n 向上取整为 align 的倍数
+func alignUp(n, align uintptr) uintptr {
+	if align == 0 {
+		return n
+	}
+	return (n + align - 1) / align * align
+}
+
+// WriteTable 以表格形式把布局写入 w，填充作为单独的行显示
+func (l *Layout) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%s size=%d align=%d padding=%d\n", l.Name, l.Size, l.Align, l.PaddingSize()); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tTYPE\tOFFSET\tSIZE\tALIGN")
+
+	padding := l.Padding
+	writePadding := func(offset uintptr) {
+		for len(padding) > 0 && padding[0].Offset == offset {
+			fmt.Fprintf(tw, "<padding>\t\t%d\t%d\t\n", padding[0].Offset, padding[0].Size)
+			padding = padding[1:]
+		}
+	}
+	for _, f := range l.Fields {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", f.Name, f.Type, f.Offset, f.Size, f.Align)
+		writePadding(f.Offset + f.Size)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,106 @@
+package layout_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"standard-library-examples/unsafe/layout"
+)
+
+// padded 字段顺序不合理，在 64 位平台上占用 24 字节
+type padded struct {
+	A bool
+	B int64
+	C bool
+	D int32
+}
+
+func TestOf(t *testing.T) {
+	var v padded
+	l, err := layout.OfValue(v)
+	if err != nil {
+		t.Fatalf("OfValue err: %v", err)
+	}
+	if l.Size != unsafe.Sizeof(v) || l.Align != unsafe.Alignof(v) {
+		t.Errorf("size = %d align = %d, want %d %d", l.Size, l.Align, unsafe.Sizeof(v), unsafe.Alignof(v))
+	}
+
+	want := []layout.Field{
+		{"A", "bool", unsafe.Offsetof(v.A), unsafe.Sizeof(v.A), unsafe.Alignof(v.A)},
+		{"B", "int64", unsafe.Offsetof(v.B), unsafe.Sizeof(v.B), unsafe.Alignof(v.B)},
+		{"C", "bool", unsafe.Offsetof(v.C), unsafe.Sizeof(v.C), unsafe.Alignof(v.C)},
+		{"D", "int32", unsafe.Offsetof(v.D), unsafe.Sizeof(v.D), unsafe.Alignof(v.D)},
+	}
+	if !reflect.DeepEqual(l.Fields, want) {
+		t.Errorf("Fields = %+v, want %+v", l.Fields, want)
+	}
+
+	var used uintptr
+	for _, f := range l.Fields {
+		used += f.Size
+	}
+	if used+l.PaddingSize() != l.Size {
+		t.Errorf("fields %d + padding %d != size %d", used, l.PaddingSize(), l.Size)
+	}
+
+	if _, err := layout.Of(reflect.TypeOf(0)); err == nil {
+		t.Errorf("Of(int) should fail")
+	}
+	if _, err := layout.OfValue(&v); err != nil {
+		t.Errorf("OfValue(pointer) err: %v", err)
+	}
+}
+
+// optimized 与 padded 字段相同，按对齐从大到小排列
+type optimized struct {
+	B int64
+	D int32
+	A bool
+	C bool
+}
+
+func TestOptimize(t *testing.T) {
+	l, _ := layout.OfValue(padded{})
+	opt := l.Optimize()
+	if opt.Size != unsafe.Sizeof(optimized{}) {
+		t.Errorf("optimized size = %d, want %d", opt.Size, unsafe.Sizeof(optimized{}))
+	}
+
+	var names []string
+	for _, f := range opt.Fields {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "B,D,A,C" {
+		t.Errorf("optimized order = %s, want B,D,A,C", got)
+	}
+}
+
+// TestZeroSizeField 末尾的零大小字段会让编译器额外填充
+func TestZeroSizeField(t *testing.T) {
+	type tail struct {
+		A int32
+		B struct{}
+	}
+	l, _ := layout.OfValue(tail{})
+	if l.PaddingSize() == 0 {
+		t.Fatalf("want trailing padding, got layout %+v", l)
+	}
+	if opt := l.Optimize(); opt.Size != unsafe.Sizeof(int32(0)) {
+		t.Errorf("optimized size = %d, want 4", opt.Size)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	l, _ := layout.OfValue(padded{})
+	var buf bytes.Buffer
+	if err := l.WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable err: %v", err)
+	}
+	t.Logf("\n%s", buf.String())
+	if !strings.Contains(buf.String(), "<padding>") {
+		t.Errorf("table does not show padding")
+	}
+}
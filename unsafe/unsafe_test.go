@@ -3,6 +3,8 @@ package unsafe_test
 import (
 	"testing"
 	"unsafe"
+
+	"standard-library-examples/unsafe/zerocopy"
 )
 
 func TestUnsafePointer(t *testing.T) {
@@ -82,39 +84,16 @@ func TestUnsafePointer6(t *testing.T) {
 	// 原因是字符串没有容量，转成切片时，容量丢失
 	t.Logf("cap of slice = %v\n", cap(*slice))
 
-	// 字符串转为切片
-	slice2 := stringToBytes(s)
-	t.Logf("stringToBytes = %v\n", slice2)
+	// 字符串转为切片，容量等于字符串长度
+	slice2 := zerocopy.StringToBytes(s)
+	t.Logf("StringToBytes = %v, cap = %d\n", slice2, cap(slice2))
 	// 切片转为字符串
-	t.Logf("bytesToString = %v\n", bytesToString(*slice))
+	t.Logf("BytesToString = %v\n", zerocopy.BytesToString(slice2))
 
 	// 底层数组是否相同
 	slice1 := []byte{97, 98, 99}
-	s2 := bytesToString(slice1)
+	s2 := zerocopy.BytesToString(slice1)
 	t.Logf("s2 = %v\n", s2)
 	slice1[0] = 'A'
 	t.Logf("s2 = %v\n", s2)
 }
-
-// 字符串转为切片
-func stringToBytes(s string) []byte {
-	return *(*[]byte)(unsafe.Pointer(&struct {
-		string
-		Cap int
-	}{s, len(s)}))
-}
-
-// 切片转为字符串
-func bytesToString(b []byte) string {
-	return *(*string)(unsafe.Pointer(&b))
-}
-
-// 新版
-func StringToBytes(s string) []byte {
-	return unsafe.Slice(unsafe.StringData(s), len(s))
-}
-
-// 新版
-func BytesToString(b []byte) string {
-	return unsafe.String(&b[0], unsafe.IntegerType(len(b)))
-}
@@ -0,0 +1,36 @@
+// Package zerocopy 在 string 和 []byte 之间做零拷贝转换
+//
+// 转换后的结果与输入共享同一块内存，使用时必须遵守以下规则：
+//
+//   - StringToBytes 返回的切片绝对不能被修改。字符串的内存可能位于只读段，
+//     修改会直接导致程序崩溃；即使不崩溃，也会破坏字符串不可变的约定，
+//     影响所有持有该字符串的代码（包括 map 的 key）。
+//   - BytesToString 返回的字符串在使用期间，原切片不能再被修改，
+//     否则字符串的内容会随之变化。
+//   - 不确定能否遵守以上规则时，请使用 []byte(s) 和 string(b) 复制数据。
+//
+// 实现基于 Go 1.20 新增的 unsafe.String、unsafe.StringData 和 unsafe.SliceData，
+// 不依赖 reflect.StringHeader、reflect.SliceHeader 的内存布局。
+package zerocopy
+
+import "unsafe"
+
+// StringToBytes 返回与 s 共享内存的 []byte，返回的切片不能被修改
+//
+// s 为空字符串时返回 nil。切片的容量等于长度，append 会分配新的内存而不会写入 s
+func StringToBytes(s string) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// BytesToString 返回与 b 共享内存的字符串，在字符串使用期间 b 不能被修改
+//
+// b 为 nil 或者空切片时返回 ""
+func BytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
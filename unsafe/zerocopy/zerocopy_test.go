@@ -0,0 +1,111 @@
+package zerocopy_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"standard-library-examples/unsafe/zerocopy"
+)
+
+func TestEmpty(t *testing.T) {
+	if b := zerocopy.StringToBytes(""); b != nil {
+		t.Errorf("StringToBytes(\"\") = %v, want nil", b)
+	}
+	if s := zerocopy.BytesToString(nil); s != "" {
+		t.Errorf("BytesToString(nil) = %q, want \"\"", s)
+	}
+	if s := zerocopy.BytesToString([]byte{}); s != "" {
+		t.Errorf("BytesToString([]byte{}) = %q, want \"\"", s)
+	}
+	if s := zerocopy.BytesToString(make([]byte, 0, 8)); s != "" {
+		t.Errorf("BytesToString(cap 8) = %q, want \"\"", s)
+	}
+}
+
+// TestShareMemory 转换结果与输入指向同一块内存
+func TestShareMemory(t *testing.T) {
+	s := strings.Repeat("abc", 3)
+	b := zerocopy.StringToBytes(s)
+	if unsafe.SliceData(b) != unsafe.StringData(s) || cap(b) != len(s) {
+		t.Errorf("StringToBytes copied the data")
+	}
+
+	b = []byte("hello")
+	s = zerocopy.BytesToString(b)
+	b[0] = 'H'
+	if s != "Hello" {
+		t.Errorf("BytesToString(b) = %q after mutation, want %q", s, "Hello")
+	}
+}
+
+// FuzzStringToBytes 与 []byte(s) 复制得到的结果比较
+func FuzzStringToBytes(f *testing.F) {
+	f.Add("")
+	f.Add("abc")
+	f.Add("琪露诺")
+	f.Fuzz(func(t *testing.T, s string) {
+		got := zerocopy.StringToBytes(s)
+		if want := []byte(s); !bytes.Equal(got, want) {
+			t.Fatalf("StringToBytes(%q) = %v, want %v", s, got, want)
+		}
+		if zerocopy.BytesToString(got) != s {
+			t.Fatalf("round trip of %q failed", s)
+		}
+	})
+}
+
+// FuzzBytesToString 与 string(b) 复制得到的结果比较
+func FuzzBytesToString(f *testing.F) {
+	f.Add([]byte(nil))
+	f.Add([]byte{230, 134, 168, 112, 105})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		got := zerocopy.BytesToString(b)
+		if want := string(b); got != want {
+			t.Fatalf("BytesToString(%v) = %q, want %q", b, got, want)
+		}
+		if !bytes.Equal(zerocopy.StringToBytes(got), b) {
+			t.Fatalf("round trip of %v failed", b)
+		}
+	})
+}
+
+// 把结果赋值给包级变量，避免编译器把转换优化掉
+var (
+	byteSink   []byte
+	stringSink string
+)
+
+// go test ./unsafe/zerocopy -bench=. -benchmem
+func BenchmarkStringToBytes(b *testing.B) {
+	s := strings.Repeat("a", 4096)
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			byteSink = []byte(s)
+		}
+	})
+	b.Run("zerocopy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			byteSink = zerocopy.StringToBytes(s)
+		}
+	})
+}
+
+func BenchmarkBytesToString(b *testing.B) {
+	buf := bytes.Repeat([]byte("a"), 4096)
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			stringSink = string(buf)
+		}
+	})
+	b.Run("zerocopy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			stringSink = zerocopy.BytesToString(buf)
+		}
+	})
+}